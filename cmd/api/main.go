@@ -11,13 +11,22 @@ import (
 	"loginbackend/config"
 	"loginbackend/features/acl"
 	"loginbackend/features/auth"
+	"loginbackend/features/roles"
 	"loginbackend/features/shared/models"
 	"loginbackend/features/tasks"
 	"loginbackend/features/users"
+	"loginbackend/internal/audit"
 	"loginbackend/internal/database"
 	httpPlatform "loginbackend/internal/http"
 	ws "loginbackend/internal/websocket"
+	"loginbackend/pkg/jwtkeys"
+	"loginbackend/pkg/mailer"
+	"loginbackend/pkg/oidc"
+	"loginbackend/pkg/password"
+	"loginbackend/pkg/storage"
+	"loginbackend/pkg/uploader"
 	"loginbackend/pkg/utils"
+	"loginbackend/pkg/webauthn"
 
 	_ "loginbackend/docs"
 
@@ -41,6 +50,17 @@ func main() {
 		log.Fatal("Erro ao inicializar Snowflake:", err)
 	}
 
+	// Hash de senha: hashes antigos são refeitos no próximo login
+	if err := utils.SetPasswordHashing(utils.PasswordHashing{
+		Algorithm:     cfg.PasswordHashAlgorithm,
+		BcryptCost:    cfg.BcryptCost,
+		Argon2Memory:  uint32(cfg.Argon2MemoryKB),
+		Argon2Time:    uint32(cfg.Argon2Time),
+		Argon2Threads: uint8(cfg.Argon2Threads),
+	}); err != nil {
+		log.Fatal(err)
+	}
+
 	// Conexão com PostgreSQL
 	db, err := database.NewPostgres(cfg.GetConnectionString())
 	if err != nil {
@@ -64,8 +84,16 @@ func main() {
 
 	seedSuperAdmin(db, cfg)
 
+	auditLogger := audit.NewLogger(db)
+
+	// Storage dos uploads (UPLOAD_PROVIDER=local ou s3)
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Inicializar Router
-	r := httpPlatform.NewRouter(cfg, redisClient)
+	r := httpPlatform.NewRouter(cfg, redisClient, auditLogger, store)
 
 	// Swagger
 	r.Get("/swagger/*", httpSwagger.Handler(
@@ -78,34 +106,107 @@ func main() {
 	hub := ws.NewHub(redisClient)
 	go hub.Run(context.Background())
 
+	// ======================================================
+	// Email (reset de senha, verificação, ...)
+	// ======================================================
+	mailSender, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jwtKeys := loadJWTKeys(cfg)
+
+	// ======================================================
+	// Roles Feature (papéis e permissões de sistema)
+	// ======================================================
+	rolesRepo := roles.NewRepository(db)
+	rolesService := roles.NewService(rolesRepo, redisClient, auditLogger)
+	rolesHandler := roles.NewHandler(rolesService)
+
+	// Política de senha (cadastro, troca e redefinição)
+	passwordPolicy, err := password.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if passwordPolicy.Breached != nil {
+		log.Printf("🔒 %d hash(es) de senhas vazadas carregado(s)", passwordPolicy.Breached.Len())
+	}
+
 	// ======================================================
 	// Auth Feature
 	// ======================================================
 	authRepo := auth.NewRepository(db)
 	authService := auth.NewService(authRepo, redisClient, auth.Config{
-		JWTSecret:     cfg.JWTSecret,
+		Keys:          jwtKeys,
 		AccessExpiry:  5 * time.Minute,
 		RefreshExpiry: 15 * time.Minute,
+		MFAExpiry:     5 * time.Minute,
+		TOTPIssuer:    cfg.TOTPIssuer,
+
+		Mailer:              mailSender,
+		AppURL:              cfg.AppURL,
+		PasswordResetExpiry: 30 * time.Minute,
+		PasswordPolicy:      passwordPolicy,
+
+		EmailVerificationExpiry: 24 * time.Hour,
+		RequireVerifiedEmail:    cfg.RequireEmailVerification,
+		MagicLinkExpiry:         15 * time.Minute,
+
+		Audit:            auditLogger,
+		MaxLoginAttempts: 10,
+		LockoutDuration:  15 * time.Minute,
+
+		ImpersonationExpiry: 15 * time.Minute,
+		RolePermissions:     rolesService,
+
+		OIDCProviders:   oidcProviders(cfg),
+		OIDCRedirectURL: cfg.OIDCRedirectURL,
+
+		PublicURL:         cfg.PublicURL,
+		OAuthAuthorizeURL: cfg.OAuthAuthorizeURL,
+
+		WebAuthn: webauthnRelyingParty(cfg),
 	})
 	authHandler := auth.NewHandler(authService)
 
-	authPath, authRoutes := auth.Routes(authHandler, redisClient)
+	authPath, authRoutes := auth.Routes(authHandler, jwtKeys, redisClient, rolesService)
 	r.Route(authPath, authRoutes)
 
+	// Chaves públicas para outros serviços validarem nossos tokens offline
+	r.Get("/.well-known/jwks.json", authHandler.JWKS)
+	r.Get("/.well-known/openid-configuration", authHandler.OpenIDConfiguration)
+
 	// ======================================================
 	// Users Feature
 	// ======================================================
 	usersRepo := users.NewRepository(db)
-	usersService := users.NewService(usersRepo)
-	usersHandler := users.NewHandler(usersService)
+	usersService := users.NewService(usersRepo, authService, authService, passwordPolicy, rolesService, auditLogger)
+	authService.SetUserProvisioner(usersService)
+	usersService.SetSignup(users.SignupConfig{
+		Mode:             cfg.SignupMode,
+		AllowedDomains:   cfg.SignupAllowedDomains,
+		InvitationExpiry: time.Duration(cfg.InvitationExpiryDays) * 24 * time.Hour,
+		Mailer:           mailSender,
+		AppURL:           cfg.AppURL,
+	})
+	usersService.SetAvatarRemover(func(userID string) error {
+		return uploader.DeleteAvatar(store, userID)
+	})
+	go runAccountPurge(usersService)
+	usersHandler := users.NewHandler(usersService, store)
 
 	usersPath, usersRoutes := users.Routes(
 		usersHandler,
-		cfg.JWTSecret,
+		jwtKeys,
 		redisClient,
+		usersService,
+		rolesService,
 	)
 	r.Route(usersPath, usersRoutes)
 
+	rolesPath, rolesRoutes := roles.Routes(rolesHandler, jwtKeys, redisClient, usersService, rolesService)
+	r.Route(rolesPath, rolesRoutes)
+
 	// ======================================================
 	// ACL Feature (Acess Control Layer + bitmask)
 	// ======================================================
@@ -113,7 +214,7 @@ func main() {
 	aclService := acl.NewService(aclRepo)
 	aclHandler := acl.NewHandler(aclService, hub)
 
-	aclPath, aclRoutes := acl.Routes(aclHandler, cfg.JWTSecret, redisClient)
+	aclPath, aclRoutes := acl.Routes(aclHandler, jwtKeys, redisClient, usersService)
 	r.Route(aclPath, aclRoutes)
 
 	// ======================================================
@@ -125,9 +226,11 @@ func main() {
 
 	tasksPath, tasksRoutes := tasks.Routes(
 		tasksHandler,
-		cfg.JWTSecret,
+		jwtKeys,
 		redisClient,
 		aclService,
+		usersService,
+		rolesService,
 	)
 	r.Route(tasksPath, tasksRoutes)
 
@@ -165,6 +268,74 @@ func main() {
 	}
 }
 
+// loadJWTKeys usa as chaves PEM quando configuradas; sem elas mantém o
+// HS256 com JWT_SECRET (nada é publicado no JWKS nesse modo).
+func loadJWTKeys(cfg *config.Config) *jwtkeys.Manager {
+	var keys *jwtkeys.Manager
+
+	if cfg.JWTSigningKey == "" {
+		log.Println("⚠️ JWT_SIGNING_KEY não definido. Usando HS256 com JWT_SECRET.")
+		keys = jwtkeys.NewHMAC(cfg.JWTSecret)
+	} else {
+		var err error
+		keys, err = jwtkeys.LoadFromFiles(cfg.JWTSigningKey, cfg.JWTVerificationKeys)
+		if err != nil {
+			log.Fatalf("❌ FATAL: erro ao carregar chaves JWT: %v", err)
+		}
+		log.Printf("🔑 %d chave(s) JWT carregada(s)", len(keys.JWKS().Keys))
+	}
+
+	keys.SetIssuer(cfg.JWTIssuer, cfg.JWTAudience)
+	return keys
+}
+
+func oidcProviders(cfg *config.Config) []*oidc.Provider {
+	providers := make([]*oidc.Provider, 0, len(cfg.OIDCProviders))
+	for _, p := range cfg.OIDCProviders {
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			Scopes:       p.Scopes,
+		}))
+	}
+	return providers
+}
+
+// webauthnRelyingParty devolve nil (passkeys desabilitadas) se não for
+// possível deduzir o domínio — APP_URL ausente e WEBAUTHN_RP_ID vazio.
+func webauthnRelyingParty(cfg *config.Config) *webauthn.RelyingParty {
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:    cfg.WebAuthnRPID,
+		RPName:  cfg.WebAuthnRPName,
+		Origins: cfg.WebAuthnOrigins,
+	})
+	if err != nil {
+		log.Printf("ℹ️ Passkeys desabilitadas: %v", err)
+		return nil
+	}
+	return rp
+}
+
+// runAccountPurge anonimiza, de hora em hora, as contas cuja exclusão
+// agendada já passou do período de carência.
+func runAccountPurge(usersService *users.Service) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		purged, err := usersService.PurgeDueDeletions()
+		if err != nil {
+			log.Printf("❌ Erro ao processar exclusões agendadas: %v", err)
+		} else if purged > 0 {
+			log.Printf("🗑️ %d conta(s) anonimizada(s)", purged)
+		}
+
+		<-ticker.C
+	}
+}
+
 func seedSuperAdmin(db *sql.DB, cfg *config.Config) {
 	if cfg.AdminEmail == "" || cfg.AdminPassword == "" {
 		log.Println("ℹ️ ADMIN_EMAIL/PASSWORD não definidos. Pulando Super Admin.")
@@ -198,6 +369,8 @@ func seedSuperAdmin(db *sql.DB, cfg *config.Config) {
 		PasswordHash: hash,
 		RoleID:       1,
 		IsActive:     true,
+		// Criado pela própria operação (via env) — não há link para clicar
+		IsEmailVerified: true,
 	}
 
 	if err := repo.Create(adminUser); err != nil {
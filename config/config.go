@@ -2,7 +2,10 @@ package config
 
 import (
 	"log"
+	"net/netip"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -21,13 +24,100 @@ type Config struct {
 	JWTSecret        string
 	AllowedOrigins   []string
 
+	// TrustedProxies são os proxies reversos (IPs ou CIDRs) cujo
+	// X-Forwarded-For/X-Real-IP é aceito como IP do cliente. Vazio: usa
+	// sempre o IP da conexão.
+	TrustedProxies []netip.Prefix
+
+	// JWTSigningKey é o PEM (RSA ou Ed25519) que assina os tokens; quando
+	// definido, substitui o JWT_SECRET. JWTVerificationKeys são chaves
+	// antigas ainda aceitas durante uma rotação.
+	JWTSigningKey       string
+	JWTVerificationKeys []string
+	// JWTIssuer/JWTAudience viram os claims iss/aud e são exigidos na validação.
+	JWTIssuer   string
+	JWTAudience string
+
 	AdminEmail    string
 	AdminPassword string
 
+	// UploadProvider escolhe onde ficam os uploads: local (UploadDir) ou
+	// s3 (qualquer serviço compatível: AWS, MinIO, R2, ...). StorageURL é
+	// a base das URLs públicas (padrão: PUBLIC_URL + /uploads).
 	UploadProvider string
 	UploadDir      string
 	AppURL         string
 	StorageURL     string
+
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	// S3ForcePathStyle usa endpoint/bucket/chave em vez de bucket.endpoint/chave
+	// (necessário no MinIO e em endpoints sem DNS por bucket).
+	S3ForcePathStyle bool
+
+	TOTPIssuer string
+
+	MailProvider string
+	MailFrom     string
+	MailDir      string
+
+	// RequireEmailVerification impede o login de contas com email não verificado.
+	RequireEmailVerification bool
+
+	// SignupMode controla o cadastro público (POST /users e login social):
+	// open (padrão), invite (só por convite) ou domain (só emails dos
+	// domínios em SignupAllowedDomains, com RequireEmailVerification
+	// obrigatório). Convites valem em qualquer modo.
+	SignupMode           string
+	SignupAllowedDomains []string
+	InvitationExpiryDays int
+
+	// OIDCProviders vem de OIDC_PROVIDERS=google,microsoft e das variáveis
+	// OIDC_<NOME>_ISSUER/_CLIENT_ID/_CLIENT_SECRET/_SCOPES de cada um.
+	OIDCProviders   []OIDCProvider
+	OIDCRedirectURL string
+
+	// PublicURL é a URL pública desta API; OAuthAuthorizeURL é a tela de
+	// consentimento do frontend (authorization_endpoint do discovery).
+	PublicURL         string
+	OAuthAuthorizeURL string
+
+	// WebAuthnRPID é o domínio das passkeys (padrão: host do APP_URL);
+	// WebAuthnOrigins são as origens do frontend aceitas nas cerimônias.
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins []string
+
+	// Política de senha. PasswordMaxAgeDays e PasswordHistorySize em zero
+	// desabilitam expiração e histórico; PasswordBreachedList é o arquivo
+	// local de hashes SHA-1 de senhas vazadas (formato do Pwned Passwords).
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordMaxAgeDays    int
+	PasswordHistorySize   int
+	PasswordBreachedList  string
+
+	// PasswordHashAlgorithm (argon2id ou bcrypt) e parâmetros das senhas
+	// novas. Hashes em outro formato são refeitos no login.
+	PasswordHashAlgorithm string
+	BcryptCost            int
+	Argon2MemoryKB        int
+	Argon2Time            int
+	Argon2Threads         int
+}
+
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 func Load() *Config {
@@ -46,23 +136,98 @@ func Load() *Config {
 		RedisPassword:    os.Getenv("REDIS_PASSWORD"),
 		JWTSecret:        os.Getenv("JWT_SECRET"),
 		AllowedOrigins:   strings.Split(os.Getenv("ALLOWED_ORIGINS"), ","),
+		TrustedProxies:   loadTrustedProxies(),
+
+		JWTSigningKey:       os.Getenv("JWT_SIGNING_KEY"),
+		JWTVerificationKeys: getEnvList("JWT_VERIFICATION_KEYS"),
+		JWTIssuer:           getEnv("JWT_ISSUER", "loginbackend"),
+		JWTAudience:         getEnv("JWT_AUDIENCE", "loginbackend-api"),
 
 		AdminEmail:    os.Getenv("ADMIN_EMAIL"),
 		AdminPassword: os.Getenv("ADMIN_PASSWORD"),
 
-		UploadProvider: os.Getenv("UPLOAD_PROVIDER"),
-		UploadDir:      os.Getenv("UPLOAD_DIR"),
+		UploadProvider: getEnv("UPLOAD_PROVIDER", "local"),
+		UploadDir:      getEnv("UPLOAD_DIR", "./uploads"),
 		AppURL:         os.Getenv("APP_URL"),
 		StorageURL:     os.Getenv("STORAGE_URL"),
+
+		S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3ForcePathStyle:  getEnvBool("S3_FORCE_PATH_STYLE", false),
+
+		TOTPIssuer: getEnv("TOTP_ISSUER", "LoginBackend"),
+
+		MailProvider: getEnv("MAIL_PROVIDER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailDir:      getEnv("MAIL_DIR", "./mails"),
+
+		RequireEmailVerification: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+
+		SignupMode:           strings.ToLower(getEnv("SIGNUP_MODE", "open")),
+		SignupAllowedDomains: getEnvList("SIGNUP_ALLOWED_DOMAINS"),
+		InvitationExpiryDays: getEnvInt("INVITATION_EXPIRY_DAYS", 7),
+
+		OIDCProviders: loadOIDCProviders(),
+
+		PasswordMinLength:     getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+		PasswordRequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+		PasswordRequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+		PasswordRequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordMaxAgeDays:    getEnvInt("PASSWORD_MAX_AGE_DAYS", 0),
+		PasswordHistorySize:   getEnvInt("PASSWORD_HISTORY_SIZE", 5),
+		PasswordBreachedList:  os.Getenv("PASSWORD_BREACHED_LIST"),
+
+		PasswordHashAlgorithm: getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		BcryptCost:            getEnvInt("BCRYPT_COST", 10),
+		Argon2MemoryKB:        getEnvInt("ARGON2_MEMORY_KB", 64*1024),
+		Argon2Time:            getEnvInt("ARGON2_TIME", 3),
+		Argon2Threads:         getEnvInt("ARGON2_THREADS", 2),
+	}
+
+	cfg.OIDCRedirectURL = getEnv("OIDC_REDIRECT_URL", cfg.AppURL+"/auth/callback")
+	cfg.PublicURL = strings.TrimSuffix(getEnv("PUBLIC_URL", "http://localhost:8080"), "/")
+	if cfg.StorageURL == "" {
+		cfg.StorageURL = cfg.PublicURL + "/uploads"
+	}
+	cfg.OAuthAuthorizeURL = getEnv("OAUTH_AUTHORIZE_URL", cfg.AppURL+"/oauth/authorize")
+
+	cfg.WebAuthnRPName = getEnv("WEBAUTHN_RP_NAME", cfg.TOTPIssuer)
+	cfg.WebAuthnRPID = os.Getenv("WEBAUTHN_RP_ID")
+	cfg.WebAuthnOrigins = getEnvList("WEBAUTHN_ORIGINS")
+	if appURL, err := url.Parse(cfg.AppURL); err == nil && appURL.Host != "" {
+		if cfg.WebAuthnRPID == "" {
+			cfg.WebAuthnRPID = appURL.Hostname()
+		}
+		if len(cfg.WebAuthnOrigins) == 0 {
+			cfg.WebAuthnOrigins = []string{appURL.Scheme + "://" + appURL.Host}
+		}
 	}
 
 	// Validação Crítica: Se faltar segredo, a aplicação NÃO SOBE.
-	if cfg.JWTSecret == "" {
-		log.Fatal("❌ FATAL: JWT_SECRET não está configurado.")
+	if cfg.JWTSecret == "" && cfg.JWTSigningKey == "" {
+		log.Fatal("❌ FATAL: nem JWT_SIGNING_KEY nem JWT_SECRET estão configurados.")
 	}
 	if cfg.PostgresPassword == "" {
 		log.Fatal("❌ FATAL: POSTGRES_PASSWORD não está configurado.")
 	}
+	switch cfg.SignupMode {
+	case "open", "invite":
+	case "domain":
+		if len(cfg.SignupAllowedDomains) == 0 {
+			log.Fatal("❌ FATAL: SIGNUP_MODE=domain exige SIGNUP_ALLOWED_DOMAINS.")
+		}
+		// Sem verificação qualquer um cadastraria ceo@dominio-permitido e
+		// entraria na hora: a restrição de domínio não valeria nada.
+		if !cfg.RequireEmailVerification {
+			log.Fatal("❌ FATAL: SIGNUP_MODE=domain exige REQUIRE_EMAIL_VERIFICATION=true.")
+		}
+	default:
+		log.Fatalf("❌ FATAL: SIGNUP_MODE inválido: %s (use open, invite ou domain)", cfg.SignupMode)
+	}
 	// Adicione validações para os outros campos de banco se desejar
 
 	return cfg
@@ -76,3 +241,92 @@ func (c *Config) GetConnectionString() string {
 		" dbname=" + c.PostgresDB +
 		" sslmode=" + c.PostgresSSLMode
 }
+
+// getEnv retorna a variável de ambiente ou o valor padrão se estiver vazia.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvBool interpreta "true/false/1/0"; qualquer outro valor usa o padrão.
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvInt interpreta um inteiro; valor ausente ou inválido usa o padrão.
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// loadOIDCProviders lê os provedores listados em OIDC_PROVIDERS. Um
+// provedor incompleto derruba a aplicação: melhor falhar no deploy do
+// que exibir um botão de login que não funciona.
+func loadOIDCProviders() []OIDCProvider {
+	var providers []OIDCProvider
+
+	for _, name := range getEnvList("OIDC_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		provider := OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Fatalf("❌ FATAL: %sISSUER e %sCLIENT_ID são obrigatórios.", prefix, prefix)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// loadTrustedProxies lê TRUSTED_PROXIES (ex: 10.0.0.0/8,172.17.0.1).
+// Endereço inválido derruba a aplicação.
+func loadTrustedProxies() []netip.Prefix {
+	var prefixes []netip.Prefix
+
+	for _, value := range getEnvList("TRUSTED_PROXIES") {
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				log.Fatalf("❌ FATAL: TRUSTED_PROXIES inválido: %s", value)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			log.Fatalf("❌ FATAL: TRUSTED_PROXIES inválido: %s", value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes
+}
+
+// getEnvList separa uma lista por vírgulas, ignorando itens vazios.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
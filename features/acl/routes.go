@@ -2,6 +2,7 @@ package acl
 
 import (
 	"loginbackend/internal/http/middleware"
+	"loginbackend/pkg/jwtkeys"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/redis/go-redis/v9"
@@ -11,10 +12,10 @@ import (
 // ROUTES
 // ============================================
 
-func Routes(handler *Handler, jwtSecret string, redisClient *redis.Client) (string, func(r chi.Router)) {
+func Routes(handler *Handler, keys *jwtkeys.Manager, redisClient *redis.Client, tokens middleware.PersonalTokenValidator) (string, func(r chi.Router)) {
 	return "/acl", func(r chi.Router) {
 		// Middleware global de autenticação
-		r.Use(middleware.AuthMiddleware(jwtSecret, redisClient))
+		r.Use(middleware.AuthMiddleware(keys, redisClient, tokens))
 
 		// ACL Management
 		r.Post("/", handler.GrantACL)
@@ -6,20 +6,29 @@ import (
 	"strings"
 	"time"
 
+	"loginbackend/internal/http/middleware"
 	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
 )
 
 type Handler struct {
-	service *Service
+	service  *Service
+	validate *validator.Validate
 }
 
 func NewHandler(service *Service) *Handler {
-	return &Handler{service: service}
+	return &Handler{
+		service:  service,
+		validate: validator.New(),
+	}
 }
 
 // Login
 // @Summary Login do usuário
-// @Description Faz login do usuário e retorna tokens JWT
+// @Description Faz login do usuário e retorna tokens JWT. Se o usuário tiver TOTP ativo,
+// @Description retorna mfa_required=true e um mfa_token para POST /auth/login/mfa.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -35,7 +44,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.service.Login(req.Email, req.Password)
+	response, err := h.service.Login(req.Email, req.Password, clientInfo(r))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -43,15 +52,23 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    response.RefreshToken,
-		Path:     "/auth",                            // O cookie só será enviado para rotas de autenticação
-		Expires:  time.Now().Add(24 * time.Hour * 7), // Deve bater com a duração no Service (ex: 7 dias)
-		HttpOnly: true,                               // Impossível de ler via JavaScript (XSS Protection)
-		Secure:   true,                               // Só envia via HTTPS (Coloque 'false' se estiver testando local sem SSL)
-		SameSite: http.SameSiteLaxMode,               // Proteção contra CSRF
-	})
+	writeLoginResponse(w, response)
+}
+
+// writeLoginResponse move o refresh token do corpo para o cookie HttpOnly.
+// Quando o login ainda aguarda o segundo fator, não há cookie a definir.
+func writeLoginResponse(w http.ResponseWriter, response *LoginResponse) {
+	if response.RefreshToken != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     "refresh_token",
+			Value:    response.RefreshToken,
+			Path:     "/auth",                            // O cookie só será enviado para rotas de autenticação
+			Expires:  time.Now().Add(24 * time.Hour * 7), // Deve bater com a duração no Service (ex: 7 dias)
+			HttpOnly: true,                               // Impossível de ler via JavaScript (XSS Protection)
+			Secure:   true,                               // Só envia via HTTPS (Coloque 'false' se estiver testando local sem SSL)
+			SameSite: http.SameSiteLaxMode,               // Proteção contra CSRF
+		})
+	}
 
 	response.RefreshToken = ""
 
@@ -72,7 +89,7 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 
 	refreshTokenString := cookie.Value
 
-	response, err := h.service.RefreshToken(refreshTokenString)
+	response, err := h.service.RefreshToken(refreshTokenString, clientInfo(r))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -80,25 +97,12 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    response.RefreshToken,
-		Path:     "/auth",
-		Expires:  time.Now().Add(24 * time.Hour * 7),
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-	})
-
-	response.RefreshToken = ""
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(httpresponse.Response{Data: response})
+	writeLoginResponse(w, response)
 }
 
+// Logout encerra a sessão do dispositivo atual (cookie refresh_token)
+// e coloca o access token apresentado na blacklist.
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
-	userID := r.Header.Get("X-User-ID")
-
 	authHeader := r.Header.Get("Authorization")
 	tokenString := ""
 	if authHeader != "" {
@@ -108,13 +112,53 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := h.service.Logout(tokenString, userID); err != nil {
+	refreshToken := ""
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		refreshToken = cookie.Value
+	}
+
+	if err := h.service.Logout(tokenString, refreshToken); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao fazer logout"})
+		return
+	}
+
+	clearRefreshCookie(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "logout realizado com sucesso"})
+}
+
+// LogoutAll
+// @Summary Sair de todos os dispositivos
+// @Description Encerra todas as sessões e invalida todos os access tokens do usuário.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response
+// @Router /auth/logout-all [post]
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.LogoutAll(claims.UserID); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao fazer logout"})
 		return
 	}
 
+	clearRefreshCookie(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "logout realizado em todos os dispositivos"})
+}
+
+func clearRefreshCookie(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "refresh_token",
 		Value:    "",
@@ -124,7 +168,86 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		Secure:   true,
 		SameSite: http.SameSiteLaxMode,
 	})
+}
+
+// ListSessions
+// @Summary Listar dispositivos logados
+// @Description Lista as sessões ativas do usuário. "current" marca o dispositivo que fez a chamada.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]SessionResponse}
+// @Router /auth/sessions [get]
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	currentToken := ""
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		currentToken = cookie.Value
+	}
+
+	sessions, err := h.service.ListSessions(claims.UserID, currentToken)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar sessões"})
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(httpresponse.Response{Message: "logout realizado com sucesso"})
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: sessions})
+}
+
+// RevokeSession
+// @Summary Encerrar um dispositivo
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /auth/sessions/{id} [delete]
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+
+	if err := h.service.RevokeSession(claims.UserID, sessionID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "sessão encerrada"})
+}
+
+// clientInfo extrai o User-Agent e o IP de origem para registrar na sessão.
+func clientInfo(r *http.Request) ClientInfo {
+	return ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: middleware.RemoteIP(r),
+	}
+}
+
+// JWKS
+// @Summary Chaves públicas de assinatura (JWKS)
+// @Description Publica as chaves usadas para validar os access tokens (RFC 7517).
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwtkeys.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.service.JWKS())
 }
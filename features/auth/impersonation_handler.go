@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Impersonate
+// @Summary Personificar usuário (admin)
+// @Description Emite um access token curto em nome do usuário, com o admin no claim "act". Troca de senha, tokens, MFA e sessões ficam bloqueados; toda requisição é auditada. Encerre com POST /auth/logout.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} Response{data=ImpersonationResponse}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /auth/impersonate/{userID} [post]
+func (h *Handler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	response, err := h.service.Impersonate(claims.UserID, chi.URLParam(r, "userID"), clientInfo(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "usuário não encontrado" {
+			status = http.StatusNotFound
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: response})
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/rbac"
+	"loginbackend/pkg/utils"
+)
+
+// RolePermissionResolver é o necessário de 'roles' para saber se um papel
+// tem permissões de sistema (implementado por roles.Service).
+type RolePermissionResolver interface {
+	RolePermissions(roleID int) (rbac.Set, error)
+}
+
+// Impersonate emite um access token em nome de targetID para o suporte
+// reproduzir o que o usuário vê. O token carrega o admin no claim "act",
+// não tem refresh nem sessão e cada requisição com ele é auditada.
+func (s *Service) Impersonate(adminID, targetID string, client ClientInfo) (*ImpersonationResponse, error) {
+	if adminID == targetID {
+		return nil, errors.New("não é possível personificar a si mesmo")
+	}
+
+	user, err := s.repo.FindUserByID(targetID)
+	if err != nil {
+		return nil, errors.New("erro ao buscar usuário")
+	}
+	if user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	if !user.IsActive {
+		return nil, errors.New("usuário inativo")
+	}
+	// Personificar alguém com permissões de sistema daria acesso às rotas
+	// administrativas com a identidade de outra pessoa.
+	if s.permissions == nil {
+		return nil, errors.New("permissões de papel não configuradas")
+	}
+	permissions, err := s.permissions.RolePermissions(user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) > 0 {
+		return nil, errors.New("não é permitido personificar um usuário com papel administrativo")
+	}
+
+	// Sem rastro, sem token.
+	if err := s.audit.Log(audit.Entry{
+		ActorID:      &adminID,
+		TargetUserID: &user.ID,
+		Action:       audit.ActionImpersonationStarted,
+		IPAddress:    client.IPAddress,
+		Metadata: map[string]any{
+			"user_agent": client.UserAgent,
+			"expires_in": int64(s.impersonationExpiry.Seconds()),
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateJWT(utils.TokenClaims{
+		UserID:       user.ID,
+		Email:        user.Email,
+		RoleID:       user.RoleID,
+		TokenVersion: user.TokenVersion,
+		Act:          &utils.ActorClaim{Subject: adminID},
+	}, s.keys, s.impersonationExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+	}
+
+	return &ImpersonationResponse{
+		User: &UserResponse{
+			ID:        user.ID,
+			Email:     user.Email,
+			Name:      user.Name,
+			RoleID:    user.RoleID,
+			CreatedAt: user.CreatedAt,
+		},
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.impersonationExpiry.Seconds()),
+	}, nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// UnlockAccount
+// @Summary Desbloquear conta (admin)
+// @Description Remove o bloqueio por excesso de tentativas de login e zera os contadores.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /auth/users/{id}/unlock [post]
+func (h *Handler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID := chi.URLParam(r, "id")
+
+	if err := h.service.UnlockAccount(claims.UserID, userID, clientInfo(r)); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "usuário não encontrado" {
+			status = http.StatusNotFound
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "conta desbloqueada"})
+}
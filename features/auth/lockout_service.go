@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/utils"
+)
+
+// Política de back-off: as primeiras falhas são livres (erro de
+// digitação); a partir de backoffThreshold cada falha dobra a espera
+// obrigatória antes da próxima tentativa, até o bloqueio total.
+const (
+	backoffThreshold = 3
+	backoffBase      = 1 * time.Second
+	backoffMax       = 5 * time.Minute
+)
+
+// checkLockout recusa a tentativa ANTES de conferir a senha, para que
+// um atacante não possa continuar testando senhas durante o bloqueio.
+func (s *Service) checkLockout(user *models.User) error {
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		minutes := int(math.Ceil(time.Until(*user.LockedUntil).Minutes()))
+		return fmt.Errorf("conta temporariamente bloqueada. Tente novamente em %d minuto(s)", minutes)
+	}
+
+	ctx := context.Background()
+	ttl, err := s.redis.TTL(ctx, fmt.Sprintf("login_backoff:%s", user.ID)).Result()
+	if err == nil && ttl > 0 {
+		seconds := int(math.Ceil(ttl.Seconds()))
+		return fmt.Errorf("muitas tentativas. Aguarde %d segundo(s)", seconds)
+	}
+
+	return nil
+}
+
+// registerFailedLogin conta a falha para a CONTA (não para o IP), o que
+// pega também ataques distribuídos entre muitos IPs.
+func (s *Service) registerFailedLogin(user *models.User, client ClientInfo) {
+	ctx := context.Background()
+	failuresKey := fmt.Sprintf("login_failures:%s", user.ID)
+
+	failures, err := s.redis.Incr(ctx, failuresKey).Result()
+	if err != nil {
+		log.Printf("⚠️ Erro ao registrar falha de login: %v", err)
+		return
+	}
+	if failures == 1 {
+		s.redis.Expire(ctx, failuresKey, s.lockoutDuration)
+	}
+
+	if failures >= int64(s.maxLoginAttempts) {
+		s.lockAccount(user, client, failures)
+		return
+	}
+
+	if failures >= backoffThreshold {
+		delay := backoffBase << (failures - backoffThreshold)
+		if delay > backoffMax || delay <= 0 {
+			delay = backoffMax
+		}
+		s.redis.Set(ctx, fmt.Sprintf("login_backoff:%s", user.ID), "1", delay)
+	}
+}
+
+// confirmPassword confere a senha atual pedida em ações sensíveis de
+// quem já está logado. Erros contam para o bloqueio como no login: um
+// access token roubado não pode virar um oráculo de senhas.
+func (s *Service) confirmPassword(user *models.User, password string, client ClientInfo) error {
+	if err := s.checkLockout(user); err != nil {
+		return err
+	}
+	if !utils.CheckPassword(password, user.PasswordHash) {
+		s.registerFailedLogin(user, client)
+		return errors.New("senha incorreta")
+	}
+	return nil
+}
+
+func (s *Service) lockAccount(user *models.User, client ClientInfo, failures int64) {
+	until := time.Now().Add(s.lockoutDuration)
+
+	if err := s.repo.LockUser(user.ID, until); err != nil {
+		log.Printf("❌ Erro ao bloquear conta %s: %v", user.ID, err)
+		return
+	}
+
+	// O bloqueio passa a valer pelo banco; os contadores recomeçam depois dele.
+	s.clearFailedLogins(user.ID)
+
+	log.Printf("🔒 Conta bloqueada após %d falhas de login: user=%s, ip=%s", failures, user.ID, client.IPAddress)
+
+	if err := s.audit.Log(audit.Entry{
+		TargetUserID: &user.ID,
+		Action:       audit.ActionAccountLocked,
+		IPAddress:    client.IPAddress,
+		Metadata: map[string]any{
+			"failed_attempts": failures,
+			"locked_until":    until,
+			"user_agent":      client.UserAgent,
+		},
+	}); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+func (s *Service) clearFailedLogins(userID string) {
+	ctx := context.Background()
+	s.redis.Del(ctx,
+		fmt.Sprintf("login_failures:%s", userID),
+		fmt.Sprintf("login_backoff:%s", userID),
+	)
+}
+
+// UnlockAccount é a ação do admin para liberar uma conta bloqueada antes
+// do prazo (ex: usuário legítimo que ligou para o suporte).
+func (s *Service) UnlockAccount(adminID, userID string, client ClientInfo) error {
+	found, err := s.repo.UnlockUser(userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("usuário não encontrado")
+	}
+
+	s.clearFailedLogins(userID)
+
+	if err := s.audit.Log(audit.Entry{
+		ActorID:      &adminID,
+		TargetUserID: &userID,
+		Action:       audit.ActionAccountUnlocked,
+		IPAddress:    client.IPAddress,
+	}); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	httpresponse "loginbackend/internal/http/response"
+)
+
+// SendMagicLink
+// @Summary Solicitar link de login
+// @Description Envia um link de login sem senha para o email, se ele estiver cadastrado.
+// @Description A resposta é sempre a mesma, exista o email ou não.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MagicLinkRequest true "Email da conta"
+// @Success 200 {object} Response
+// @Router /auth/magic-link [post]
+func (h *Handler) SendMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req MagicLinkRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SendMagicLink(req.Email); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao processar solicitação"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{
+		Message: "se o email estiver cadastrado, você receberá um link de acesso",
+	})
+}
+
+// ConsumeMagicLink
+// @Summary Entrar com link de login
+// @Description Troca o token do link (uso único) pelos tokens JWT, como em /auth/login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ConsumeMagicLinkRequest true "Token recebido por email"
+// @Success 200 {object} Response{data=LoginResponse}
+// @Failure 401 {object} Response
+// @Router /auth/magic-link/consume [post]
+func (h *Handler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req ConsumeMagicLinkRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.service.ConsumeMagicLink(req.Token, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	writeLoginResponse(w, response)
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/pkg/mailer"
+	"loginbackend/pkg/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SendMagicLink envia um link de login de uso único. Como no reset de
+// senha, a resposta para quem chama não revela se o email existe.
+func (s *Service) SendMagicLink(email string) error {
+	user, err := s.repo.FindUserByEmail(email)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return nil
+	}
+
+	// Como em ForgotPassword: falhas daqui em diante só existem para
+	// contas cadastradas, então viram log em vez de 500.
+	if err := s.sendMagicLink(user); err != nil {
+		log.Printf("❌ Erro ao enviar link de login para %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// sendMagicLink grava o token novo e envia o link. O link anterior só é
+// invalidado depois que o novo saiu (ver sendPasswordReset).
+func (s *Service) sendMagicLink(user *models.User) error {
+	ctx := context.Background()
+
+	throttleKey := fmt.Sprintf("magic_link_throttle:%s", user.ID)
+	fresh, err := s.redis.SetNX(ctx, throttleKey, "1", magicLinkThrottle).Result()
+	if err != nil {
+		return fmt.Errorf("erro ao registrar pedido de link: %w", err)
+	}
+	if !fresh {
+		return nil
+	}
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return fmt.Errorf("erro ao gerar link de login: %w", err)
+	}
+	tokenHash := utils.HashToken(token)
+
+	payload, err := json.Marshal(magicLink{UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return err
+	}
+
+	linkKey := fmt.Sprintf("magic_link:%s", tokenHash)
+	if err := s.redis.Set(ctx, linkKey, payload, s.magicLinkExpiry).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar link de login: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/magic-login?token=%s", strings.TrimRight(s.appURL, "/"), token)
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Seu link de acesso",
+		Body: fmt.Sprintf(
+			"Olá, %s.\n\nUse o link abaixo para entrar sem senha (válido por %d minutos, uma única vez):\n\n%s\n\nSe não foi você que pediu, ignore este email.",
+			user.Name, int(s.magicLinkExpiry.Minutes()), link,
+		),
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		s.redis.Del(ctx, linkKey)
+		return fmt.Errorf("erro ao enviar email: %w", err)
+	}
+
+	// Só o último link enviado vale: apaga o anterior, se houver.
+	userKey := fmt.Sprintf("magic_link_user:%s", user.ID)
+	if previous, err := s.redis.Get(ctx, userKey).Result(); err == nil {
+		s.redis.Del(ctx, fmt.Sprintf("magic_link:%s", previous))
+	}
+	if err := s.redis.Set(ctx, userKey, tokenHash, s.magicLinkExpiry).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar link de login: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeMagicLink troca o link (uso único, GETDEL) pelos tokens. O link
+// substitui só a senha: com TOTP ou passkey, o segundo fator continua
+// valendo. Quem abriu o link provou acesso ao email, que passa a contar
+// como verificado.
+func (s *Service) ConsumeMagicLink(token string, client ClientInfo) (*LoginResponse, error) {
+	ctx := context.Background()
+	tokenHash := utils.HashToken(token)
+
+	payload, err := s.redis.GetDel(ctx, fmt.Sprintf("magic_link:%s", tokenHash)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, errors.New("link de login inválido ou expirado")
+		}
+		return nil, fmt.Errorf("erro ao ler link de login: %w", err)
+	}
+
+	var link magicLink
+	if err := json.Unmarshal(payload, &link); err != nil {
+		return nil, errors.New("link de login inválido ou expirado")
+	}
+	s.redis.Del(ctx, fmt.Sprintf("magic_link_user:%s", link.UserID))
+
+	user, err := s.repo.FindUserByID(link.UserID)
+	if err != nil || user == nil || user.Email != link.Email {
+		return nil, errors.New("link de login inválido ou expirado")
+	}
+	if !user.IsActive {
+		return nil, errors.New("usuário inativo")
+	}
+	if err := s.checkLockout(user); err != nil {
+		return nil, err
+	}
+
+	if !user.IsEmailVerified {
+		if _, err := s.repo.MarkEmailVerified(user.ID, user.Email); err != nil {
+			return nil, err
+		}
+		user.IsEmailVerified = true
+	}
+
+	methods, err := s.mfaMethods(user)
+	if err != nil {
+		return nil, errors.New("erro ao buscar usuário")
+	}
+	if len(methods) > 0 {
+		return s.createMFAChallenge(user.ID, methods)
+	}
+
+	return s.issueTokens(user, client)
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+)
+
+// VerifyMFA
+// @Summary Segunda etapa do login (TOTP)
+// @Description Troca o mfa_token + código TOTP (ou código de recuperação) pelos tokens JWT
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyMFARequest true "Desafio e código"
+// @Success 200 {object} Response{data=LoginResponse}
+// @Failure 401 {object} Response
+// @Router /auth/login/mfa [post]
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req VerifyMFARequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.service.VerifyMFA(req.MFAToken, req.Code, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	writeLoginResponse(w, response)
+}
+
+// TotpStatus
+// @Summary Status do TOTP
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=TotpStatusResponse}
+// @Router /auth/mfa/totp [get]
+func (h *Handler) TotpStatus(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := h.service.TotpStatus(claims.UserID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: status})
+}
+
+// SetupTotp
+// @Summary Iniciar cadastro do TOTP
+// @Description Gera um segredo e a URI otpauth:// para o QR code. Só passa a valer após /confirm.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=TotpSetupResponse}
+// @Router /auth/mfa/totp/setup [post]
+func (h *Handler) SetupTotp(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	setup, err := h.service.SetupTotp(claims.UserID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: setup})
+}
+
+// ConfirmTotp
+// @Summary Confirmar cadastro do TOTP
+// @Description Valida o primeiro código, ativa o TOTP e retorna os códigos de recuperação
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TotpCodeRequest true "Código do app autenticador"
+// @Success 200 {object} Response{data=RecoveryCodesResponse}
+// @Router /auth/mfa/totp/confirm [post]
+func (h *Handler) ConfirmTotp(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TotpCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.ConfirmTotp(claims.UserID, req.Code)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{
+		Message: "TOTP ativado. Guarde os códigos de recuperação em local seguro",
+		Data:    codes,
+	})
+}
+
+// DisableTotp
+// @Summary Desativar TOTP
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DisableTotpRequest true "Senha atual e código"
+// @Success 200 {object} Response
+// @Router /auth/mfa/totp/disable [post]
+func (h *Handler) DisableTotp(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DisableTotpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DisableTotp(claims.UserID, req, clientInfo(r)); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "TOTP desativado"})
+}
+
+// RegenerateRecoveryCodes
+// @Summary Gerar novos códigos de recuperação
+// @Description Invalida os códigos anteriores. Exige um código TOTP válido.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TotpCodeRequest true "Código do app autenticador"
+// @Success 200 {object} Response{data=RecoveryCodesResponse}
+// @Router /auth/mfa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TotpCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(claims.UserID, req.Code)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: codes})
+}
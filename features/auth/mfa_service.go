@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/pkg/totp"
+	"loginbackend/pkg/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	recoveryCodeCount = 10
+	maxMFAAttempts    = 5
+)
+
+var errInvalidMFACode = errors.New("código inválido")
+
+// createMFAChallenge emite o token opaco que liga a etapa da senha à
+// etapa do segundo fator. Só o hash vai para o Redis, com TTL curto.
+func (s *Service) createMFAChallenge(userID string, methods []string) (*LoginResponse, error) {
+	mfaToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar desafio MFA: %w", err)
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("mfa_pending:%s", utils.HashToken(mfaToken))
+
+	if err := s.redis.Set(ctx, key, userID, s.mfaExpiry).Err(); err != nil {
+		return nil, fmt.Errorf("erro ao salvar desafio MFA: %w", err)
+	}
+
+	return &LoginResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		MFAMethods:  methods,
+		ExpiresIn:   int64(s.mfaExpiry.Seconds()),
+	}, nil
+}
+
+// mfaMethods lista os segundos fatores ativos do usuário (vazio = login
+// só com o primeiro fator).
+func (s *Service) mfaMethods(user *models.User) ([]string, error) {
+	var methods []string
+	if user.TotpEnabled {
+		methods = append(methods, MFAMethodTotp)
+	}
+
+	hasPasskey, err := s.repo.HasWebAuthnCredentials(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hasPasskey {
+		methods = append(methods, MFAMethodWebAuthn)
+	}
+
+	return methods, nil
+}
+
+// VerifyMFA conclui o login de quem tem TOTP ativo.
+func (s *Service) VerifyMFA(mfaToken, code string, client ClientInfo) (*LoginResponse, error) {
+	return s.completeMFA(mfaToken, client, func(user *models.User) error {
+		return s.verifySecondFactor(user, code)
+	})
+}
+
+// completeMFA troca o desafio pelos tokens se verify aceitar o segundo
+// fator. O desafio é descartado após sucesso ou após maxMFAAttempts
+// falhas, somando TOTP e passkey. Cada falha conta também para o
+// bloqueio da conta: um novo login com a senha não zera as tentativas.
+func (s *Service) completeMFA(mfaToken string, client ClientInfo, verify func(*models.User) error) (*LoginResponse, error) {
+	ctx := context.Background()
+	tokenHash := utils.HashToken(mfaToken)
+	key := fmt.Sprintf("mfa_pending:%s", tokenHash)
+	attemptsKey := fmt.Sprintf("mfa_attempts:%s", tokenHash)
+	webauthnKey := fmt.Sprintf("mfa_webauthn:%s", tokenHash)
+
+	userID, err := s.redis.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, errors.New("desafio MFA inválido ou expirado")
+		}
+		return nil, fmt.Errorf("erro ao ler desafio MFA: %w", err)
+	}
+
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil || !user.IsActive {
+		return nil, errors.New("desafio MFA inválido ou expirado")
+	}
+
+	if err := s.checkLockout(user); err != nil {
+		return nil, err
+	}
+
+	if err := verify(user); err != nil {
+		s.registerFailedLogin(user, client)
+		attempts, _ := s.redis.Incr(ctx, attemptsKey).Result()
+		s.redis.Expire(ctx, attemptsKey, s.mfaExpiry)
+		if attempts >= maxMFAAttempts {
+			s.redis.Del(ctx, key, attemptsKey, webauthnKey)
+			return nil, errors.New("muitas tentativas. Faça login novamente")
+		}
+		return nil, err
+	}
+
+	// Uso único: quem chegar depois com o mesmo token perde a corrida.
+	deleted, err := s.redis.Del(ctx, key, attemptsKey, webauthnKey).Result()
+	if err != nil || deleted == 0 {
+		return nil, errors.New("desafio MFA inválido ou expirado")
+	}
+
+	return s.issueTokens(user, client)
+}
+
+// verifySecondFactor aceita um código TOTP ou, na falta do celular,
+// um código de recuperação (que é consumido).
+func (s *Service) verifySecondFactor(user *models.User, code string) error {
+	if !user.TotpEnabled || user.TotpSecret == nil {
+		return errors.New("TOTP não está ativo para este usuário")
+	}
+
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		return s.checkTotpCode(user.ID, *user.TotpSecret, code)
+	}
+
+	used, err := s.repo.UseRecoveryCode(user.ID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errInvalidMFACode
+	}
+
+	return nil
+}
+
+// checkTotpCode valida o código e impede que o mesmo código (mesmo
+// passo de 30s) seja aceito duas vezes.
+func (s *Service) checkTotpCode(userID, secret, code string) error {
+	step, ok := totp.Validate(code, secret, time.Now())
+	if !ok {
+		return errInvalidMFACode
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("totp_used:%s:%d", userID, step)
+
+	// A janela de aceitação é ±1 passo, então 3 períodos cobrem qualquer replay possível.
+	fresh, err := s.redis.SetNX(ctx, key, "1", 3*totp.Period).Result()
+	if err != nil {
+		return fmt.Errorf("erro ao registrar uso do código: %w", err)
+	}
+	if !fresh {
+		return errors.New("código já utilizado, aguarde o próximo")
+	}
+
+	return nil
+}
+
+// SetupTotp gera (ou regenera) um segredo pendente de confirmação.
+// Enquanto não confirmado, o login continua só com senha.
+func (s *Service) SetupTotp(userID string) (*TotpSetupResponse, error) {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	if user.TotpEnabled {
+		return nil, errors.New("TOTP já está ativo. Desative antes de configurar novamente")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveTotpSecret(userID, secret); err != nil {
+		return nil, fmt.Errorf("erro ao salvar segredo TOTP: %w", err)
+	}
+
+	return &TotpSetupResponse{
+		Secret: secret,
+		URI:    totp.URI(s.totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTotp ativa o TOTP após o usuário provar que o app foi configurado,
+// e devolve os códigos de recuperação em texto (única vez).
+func (s *Service) ConfirmTotp(userID, code string) (*RecoveryCodesResponse, error) {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	if user.TotpEnabled {
+		return nil, errors.New("TOTP já está ativo")
+	}
+	if user.TotpSecret == nil {
+		return nil, errors.New("configure o TOTP antes de confirmar")
+	}
+
+	if err := s.checkTotpCode(user.ID, *user.TotpSecret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableTotp(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTotp exige senha E segundo fator — um access token roubado
+// sozinho não pode remover a proteção da conta. Erros em qualquer um
+// deles contam para o bloqueio, como no login.
+func (s *Service) DisableTotp(userID string, req DisableTotpRequest, client ClientInfo) error {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil {
+		return errors.New("usuário não encontrado")
+	}
+
+	if err := s.confirmPassword(user, req.Password, client); err != nil {
+		return err
+	}
+
+	if err := s.verifySecondFactor(user, req.Code); err != nil {
+		s.registerFailedLogin(user, client)
+		return err
+	}
+
+	return s.repo.DisableTotp(userID)
+}
+
+// RegenerateRecoveryCodes invalida os códigos antigos. Exige um código
+// TOTP válido (não aceita código de recuperação para gerar outros).
+func (s *Service) RegenerateRecoveryCodes(userID, code string) (*RecoveryCodesResponse, error) {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	if !user.TotpEnabled || user.TotpSecret == nil {
+		return nil, errors.New("TOTP não está ativo para este usuário")
+	}
+
+	if err := s.checkTotpCode(user.ID, *user.TotpSecret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// TotpStatus informa se o TOTP está ativo e quantos códigos de recuperação restam.
+func (s *Service) TotpStatus(userID string) (*TotpStatusResponse, error) {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	status := &TotpStatusResponse{Enabled: user.TotpEnabled}
+	if user.TotpEnabled {
+		remaining, err := s.repo.CountRecoveryCodes(userID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao contar códigos de recuperação: %w", err)
+		}
+		status.RecoveryCodesRemaining = remaining
+	}
+
+	return status, nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/pkg/utils"
+)
+
+// recoveryCodesDB guarda user_recovery_codes em memória: hash → usado.
+type recoveryCodesDB struct {
+	mu    sync.Mutex
+	codes map[string]bool
+}
+
+var recoveryCodesDBs sync.Map
+
+func init() {
+	sql.Register("auth_recovery_fake", recoveryDriver{})
+}
+
+type recoveryDriver struct{}
+
+func (recoveryDriver) Open(name string) (driver.Conn, error) {
+	db, ok := recoveryCodesDBs.Load(name)
+	if !ok {
+		return nil, errors.New("recoveryCodesDB não registrado")
+	}
+	return &recoveryConn{db: db.(*recoveryCodesDB)}, nil
+}
+
+type recoveryConn struct{ db *recoveryCodesDB }
+
+func (c *recoveryConn) Prepare(query string) (driver.Stmt, error) {
+	return &recoveryStmt{db: c.db, query: query}, nil
+}
+func (c *recoveryConn) Close() error { return nil }
+func (c *recoveryConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transações não suportadas")
+}
+
+type recoveryStmt struct {
+	db    *recoveryCodesDB
+	query string
+}
+
+func (s *recoveryStmt) Close() error  { return nil }
+func (s *recoveryStmt) NumInput() int { return -1 }
+
+// Exec implementa o UPDATE de UseRecoveryCode: só marca código ainda não usado.
+func (s *recoveryStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.Contains(s.query, "UPDATE user_recovery_codes") {
+		return nil, errors.New("comando inesperado: " + s.query)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	hash := args[1].(string)
+	used, exists := s.db.codes[hash]
+	if !exists || used {
+		return driver.RowsAffected(0), nil
+	}
+	s.db.codes[hash] = true
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recoveryStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("consulta inesperada: " + s.query)
+}
+
+func newRecoveryTestService(t *testing.T, codes ...string) *Service {
+	t.Helper()
+
+	fake := &recoveryCodesDB{codes: map[string]bool{}}
+	for _, code := range codes {
+		fake.codes[utils.HashToken(utils.NormalizeRecoveryCode(code))] = false
+	}
+	recoveryCodesDBs.Store(t.Name(), fake)
+	t.Cleanup(func() { recoveryCodesDBs.Delete(t.Name()) })
+
+	db, err := sql.Open("auth_recovery_fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewRepository(db), nil, Config{})
+}
+
+func totpUser() *models.User {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	return &models.User{ID: "1", TotpEnabled: true, TotpSecret: &secret}
+}
+
+func TestRecoveryCodeSingleUse(t *testing.T) {
+	service := newRecoveryTestService(t, "abcde-fghij", "klmno-pqrst")
+	user := totpUser()
+
+	if err := service.verifySecondFactor(user, "abcde-fghij"); err != nil {
+		t.Fatalf("primeiro uso deveria passar: %v", err)
+	}
+	// Mesmo código com outra grafia continua sendo o mesmo código.
+	for _, code := range []string{"abcde-fghij", "ABCDE-FGHIJ", "abcdefghij", " abcde fghij "} {
+		if err := service.verifySecondFactor(user, code); !errors.Is(err, errInvalidMFACode) {
+			t.Errorf("reuso de %q: got %v, want errInvalidMFACode", code, err)
+		}
+	}
+
+	if err := service.verifySecondFactor(user, "KLMNO-PQRST"); err != nil {
+		t.Fatalf("o outro código continua valendo: %v", err)
+	}
+}
+
+func TestRecoveryCodeUnknown(t *testing.T) {
+	service := newRecoveryTestService(t, "abcde-fghij")
+
+	if err := service.verifySecondFactor(totpUser(), "zzzzz-zzzzz"); !errors.Is(err, errInvalidMFACode) {
+		t.Fatalf("código inexistente: got %v, want errInvalidMFACode", err)
+	}
+
+	disabled := totpUser()
+	disabled.TotpEnabled = false
+	if err := service.verifySecondFactor(disabled, "abcde-fghij"); err == nil {
+		t.Fatal("sem TOTP ativo o código de recuperação não vale")
+	}
+}
@@ -1,6 +1,10 @@
 package auth
 
-import "time"
+import (
+	"time"
+
+	"loginbackend/pkg/webauthn"
+)
 
 // DTOs para requests/responses
 type LoginRequest struct {
@@ -8,12 +12,30 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// LoginResponse é devolvido tanto no login completo quanto no login que
+// exige segundo fator. No segundo caso só MFARequired/MFAToken/MFAMethods
+// vêm preenchidos e o cliente deve chamar POST /auth/login/mfa (totp) ou
+// POST /auth/login/mfa/webauthn (passkey).
 type LoginResponse struct {
-	User         *UserResponse `json:"user"`
-	AccessToken  string        `json:"access_token"`
-	RefreshToken string        `json:"refresh_token"`
-	TokenType    string        `json:"token_type"`
-	ExpiresIn    int64         `json:"expires_in"`
+	User         *UserResponse `json:"user,omitempty"`
+	AccessToken  string        `json:"access_token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	TokenType    string        `json:"token_type,omitempty"`
+	ExpiresIn    int64         `json:"expires_in,omitempty"`
+	MFARequired  bool          `json:"mfa_required,omitempty"`
+	MFAToken     string        `json:"mfa_token,omitempty"`
+	MFAMethods   []string      `json:"mfa_methods,omitempty"`
+	// PasswordExpired indica que a senha passou da idade máxima da política.
+	PasswordExpired bool `json:"password_expired,omitempty"`
+}
+
+// ImpersonationResponse não traz refresh token: a personificação acaba
+// quando o access token expira (ou no logout).
+type ImpersonationResponse struct {
+	User        *UserResponse `json:"user"`
+	AccessToken string        `json:"access_token"`
+	TokenType   string        `json:"token_type"`
+	ExpiresIn   int64         `json:"expires_in"`
 }
 
 type RefreshRequest struct {
@@ -27,3 +49,378 @@ type UserResponse struct {
 	RoleID    int       `json:"role_id"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// VerifyMFARequest completa o login em duas etapas. Code aceita tanto o
+// código de 6 dígitos do app autenticador quanto um código de recuperação.
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// TotpSetupResponse traz o segredo em texto (para digitação manual) e a
+// URI otpauth:// (para o frontend renderizar o QR code).
+type TotpSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TotpCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTotpRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// RecoveryCodesResponse só é devolvido uma vez — o banco guarda apenas o hash.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TotpStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// ForgotPasswordRequest inicia o fluxo de recuperação de conta.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest conclui a recuperação com o token recebido por email.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// ResendVerificationRequest pede um novo link de verificação. É público
+// (por email) porque, com REQUIRE_EMAIL_VERIFICATION, a conta não loga.
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// emailVerification é o conteúdo guardado no Redis para cada link.
+// MagicLinkRequest pede um link de login sem senha.
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ConsumeMagicLinkRequest troca o token do link pelos tokens de login.
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// magicLink é guardado no Redis entre o envio e o uso do link. O email
+// invalida o link se a conta trocar de email nesse intervalo.
+type magicLink struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type emailVerification struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// ClientInfo identifica o dispositivo que está fazendo login/refresh.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session mapeia a tabela 'user_sessions' (um registro por dispositivo).
+type Session struct {
+	ID         string
+	UserID     string
+	TokenHash  string
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+
+	// ClientID/Scope só existem em sessões abertas por um cliente OAuth.
+	ClientID string
+	Scope    string
+}
+
+// RotatedToken é um refresh token que já foi trocado por outro.
+// Vê-lo de novo significa que alguém guardou uma cópia do cookie.
+type RotatedToken struct {
+	SessionID string
+	UserID    string
+	RotatedAt time.Time
+}
+
+// Motivos gravados em user_sessions.revoked_reason
+const (
+	RevokedLogout          = "logout"
+	RevokedByUser          = "user_revoked"
+	RevokedReuseDetected   = "reuse_detected"
+	RevokedPasswordReset   = "password_reset"
+	RevokedPasswordChanged = "password_changed"
+	RevokedLogoutAll       = "logout_all"
+	RevokedConsent         = "consent_revoked"
+	RevokedClientDeleted   = "client_deleted"
+)
+
+// SessionResponse é a visão da sessão devolvida em GET /auth/sessions.
+// Current indica a sessão do próprio dispositivo que fez a chamada.
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+	ClientID   string    `json:"client_id,omitempty"`
+}
+
+// Identity vincula o "sub" de um provedor OIDC a um usuário local.
+type Identity struct {
+	ID        string
+	UserID    string
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// IdentityResponse é a visão devolvida em GET /auth/identities.
+type IdentityResponse struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuthorizationURLResponse é para onde o frontend deve mandar o usuário.
+type AuthorizationURLResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackRequest repassa o code/state que o provedor devolveu no
+// redirect para o frontend.
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// oidcState é guardado no Redis entre o início do fluxo e o callback.
+// LinkUserID preenchido indica vínculo a uma conta já logada.
+type oidcState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	LinkUserID   string `json:"link_user_id,omitempty"`
+}
+
+// ============================================
+// SERVIDOR OAUTH2
+// ============================================
+
+// Grants aceitos em oauth_clients.grant_types
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+type OAuthClient struct {
+	ID           string
+	Name         string
+	SecretHash   string
+	RedirectURIs []string
+	Scopes       []string
+	GrantTypes   []string
+	CreatedBy    string
+	IsActive     bool
+	CreatedAt    time.Time
+}
+
+// IsPublic indica cliente sem segredo (SPA/CLI): só entra com PKCE.
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"required,min=3,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"dive,url"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,required,excludesall= "`
+	GrantTypes   []string `json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code refresh_token client_credentials"`
+	// Confidential gera um client_secret (backends). SPAs e CLIs são públicos.
+	Confidential bool `json:"confidential"`
+}
+
+type OAuthClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	GrantTypes   []string  `json:"grant_types"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+	// ClientSecret só aparece na criação — guardamos apenas o hash.
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizeRequest são os parâmetros do pedido de autorização, repassados
+// pela tela de consentimento do frontend (que tem a sessão do usuário).
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" validate:"required,eq=code"`
+	ClientID            string `json:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri" validate:"required"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"code_challenge" validate:"required,min=43,max=128"`
+	CodeChallengeMethod string `json:"code_challenge_method" validate:"required,eq=S256"`
+	// Approve é a decisão do usuário (ignorado em GET /auth/oauth/authorize).
+	Approve bool `json:"approve"`
+}
+
+// AuthorizeInfoResponse alimenta a tela de consentimento.
+type AuthorizeInfoResponse struct {
+	ClientName   string   `json:"client_name"`
+	Scopes       []string `json:"scopes"`
+	ConsentGiven bool     `json:"consent_given"`
+}
+
+// AuthorizeResponse diz para onde o frontend deve redirecionar o usuário.
+type AuthorizeResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// TokenResponse segue a RFC 6749 (sem o envelope Response da API).
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OAuthError é o erro padrão do endpoint de token (RFC 6749, seção 5.2).
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	status      int
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+type UserInfoResponse struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+}
+
+type ConsentResponse struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	GrantedAt  time.Time `json:"granted_at"`
+}
+
+// oauthCode é o authorization code guardado no Redis até o /token.
+type oauthCode struct {
+	ClientID      string `json:"client_id"`
+	UserID        string `json:"user_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	Nonce         string `json:"nonce,omitempty"`
+	CodeChallenge string `json:"code_challenge"`
+	AuthTime      int64  `json:"auth_time"`
+}
+
+// TokenRequest são os campos (form-urlencoded) aceitos em POST /auth/oauth/token.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	ClientID     string
+	ClientSecret string
+}
+
+// ============================================
+// PASSKEYS (WEBAUTHN)
+// ============================================
+
+// Segundos fatores anunciados em LoginResponse.MFAMethods.
+const (
+	MFAMethodTotp     = "totp"
+	MFAMethodWebAuthn = "webauthn"
+)
+
+// WebAuthnCredential é um autenticador cadastrado pelo usuário.
+type WebAuthnCredential struct {
+	ID           string
+	UserID       string
+	CredentialID []byte
+	PublicKey    []byte
+	Algorithm    int64
+	SignCount    int64
+	Transports   []string
+	Name         string
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+// WebAuthnCredentialResponse é a visão devolvida em GET /auth/webauthn/credentials.
+type WebAuthnCredentialResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// BeginWebAuthnRegistrationRequest exige a senha: um access token roubado
+// sozinho não pode cadastrar uma passkey do atacante na conta.
+type BeginWebAuthnRegistrationRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type FinishWebAuthnRegistrationRequest struct {
+	Name       string                        `json:"name" validate:"required,min=1,max=100"`
+	Credential webauthn.RegistrationResponse `json:"credential"`
+}
+
+// BeginWebAuthnLoginRequest: o navegador sempre oferece as passkeys que
+// tiver; o email, se informado, só restringe o login àquela conta.
+type BeginWebAuthnLoginRequest struct {
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+type FinishWebAuthnLoginRequest struct {
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+type BeginWebAuthnMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+// VerifyWebAuthnMFARequest completa o login em duas etapas com uma passkey.
+type VerifyWebAuthnMFARequest struct {
+	MFAToken   string                     `json:"mfa_token" validate:"required"`
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+type DeleteWebAuthnCredentialRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// webauthnChallenge é guardado no Redis entre o begin e o finish.
+// UserID vazio indica login por passkey descoberta pelo navegador.
+type webauthnChallenge struct {
+	Challenge string `json:"challenge"`
+	UserID    string `json:"user_id,omitempty"`
+}
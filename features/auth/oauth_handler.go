@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OpenIDConfiguration
+// @Summary Discovery OpenID Connect
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/openid-configuration [get]
+func (h *Handler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.service.OpenIDConfiguration())
+}
+
+// AuthorizeInfo
+// @Summary Dados da tela de consentimento
+// @Description Valida o pedido de autorização (mesmos parâmetros da RFC 6749 na query) e retorna o cliente e os escopos.
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Redirect URI registrada"
+// @Param response_type query string true "code"
+// @Param scope query string false "Escopos separados por espaço"
+// @Param code_challenge query string true "PKCE S256"
+// @Param code_challenge_method query string true "S256"
+// @Success 200 {object} Response{data=AuthorizeInfoResponse}
+// @Failure 400 {object} Response
+// @Router /auth/oauth/authorize [get]
+func (h *Handler) AuthorizeInfo(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	req := AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	info, err := h.service.AuthorizeInfo(claims.UserID, req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: info})
+}
+
+// Authorize
+// @Summary Aprovar ou negar autorização
+// @Description Registra a decisão do usuário e retorna a URL de volta ao cliente (com code ou error=access_denied).
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AuthorizeRequest true "Pedido de autorização + decisão"
+// @Success 200 {object} Response{data=AuthorizeResponse}
+// @Failure 400 {object} Response
+// @Router /auth/oauth/authorize [post]
+func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req AuthorizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.service.Authorize(claims.UserID, req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: response})
+}
+
+// Token
+// @Summary Endpoint de token OAuth2
+// @Description grant_type = authorization_code (com PKCE), refresh_token ou client_credentials.
+// @Description Autenticação do cliente por HTTP Basic ou client_id/client_secret no form.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} OAuthError
+// @Failure 401 {object} OAuthError
+// @Router /auth/oauth/token [post]
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(OAuthError{Code: "invalid_request"})
+		return
+	}
+
+	req := TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+
+	// HTTP Basic tem precedência; os valores vêm form-encoded (RFC 6749, 2.3.1).
+	if id, secret, ok := r.BasicAuth(); ok {
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	response, err := h.service.Token(req, clientInfo(r))
+	if err != nil {
+		var oauthErr *OAuthError
+		if !errors.As(err, &oauthErr) {
+			oauthErr = newOAuthError(http.StatusInternalServerError, "server_error", "")
+		}
+		if oauthErr.Code == "invalid_client" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		w.WriteHeader(oauthErr.Status())
+		json.NewEncoder(w).Encode(oauthErr)
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// UserInfo
+// @Summary UserInfo (OIDC)
+// @Description Dados do usuário liberados pelos escopos do access token (exige openid).
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} UserInfoResponse
+// @Failure 403 {object} Response
+// @Router /auth/oauth/userinfo [get]
+func (h *Handler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.service.UserInfo(claims)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	// A spec do OIDC define o corpo sem envelope.
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// ListConsents
+// @Summary Aplicativos autorizados
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]ConsentResponse}
+// @Router /auth/oauth/consents [get]
+func (h *Handler) ListConsents(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	consents, err := h.service.ListConsents(claims.UserID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar aplicativos"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: consents})
+}
+
+// RevokeConsent
+// @Summary Revogar acesso de um aplicativo
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /auth/oauth/consents/{clientID} [delete]
+func (h *Handler) RevokeConsent(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.RevokeConsent(claims.UserID, chi.URLParam(r, "clientID")); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "acesso revogado"})
+}
+
+// CreateOAuthClient
+// @Summary Registrar cliente OAuth (admin)
+// @Description O client_secret (clientes confidenciais) só é exibido nesta resposta.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateOAuthClientRequest true "Dados do cliente"
+// @Success 201 {object} Response{data=OAuthClientResponse}
+// @Failure 400 {object} Response
+// @Router /auth/oauth/clients [post]
+func (h *Handler) CreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateOAuthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	client, err := h.service.CreateOAuthClient(claims.UserID, req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: client})
+}
+
+// ListOAuthClients
+// @Summary Listar clientes OAuth (admin)
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]OAuthClientResponse}
+// @Router /auth/oauth/clients [get]
+func (h *Handler) ListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.service.ListOAuthClients()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar clientes"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: clients})
+}
+
+// DeleteOAuthClient
+// @Summary Remover cliente OAuth (admin)
+// @Description Remove o cliente e revoga todos os tokens emitidos para ele.
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /auth/oauth/clients/{clientID} [delete]
+func (h *Handler) DeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteOAuthClient(chi.URLParam(r, "clientID")); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "cliente removido"})
+}
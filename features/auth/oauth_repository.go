@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreateOAuthClient(client OAuthClient) error {
+	_, err := r.db.Exec(`
+		INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, scopes, grant_types, created_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+	`, client.ID, client.Name, client.SecretHash,
+		pq.Array(client.RedirectURIs), pq.Array(client.Scopes), pq.Array(client.GrantTypes), client.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("erro ao criar cliente oauth: %w", err)
+	}
+	return nil
+}
+
+// FindOAuthClient busca um cliente ativo pelo client_id.
+func (r *Repository) FindOAuthClient(clientID string) (*OAuthClient, error) {
+	row := r.db.QueryRow(`
+		SELECT id, name, COALESCE(secret_hash, ''), redirect_uris, scopes, grant_types,
+		       COALESCE(created_by::text, ''), is_active, created_at
+		FROM oauth_clients
+		WHERE id = $1 AND is_active = true
+	`, clientID)
+
+	client, err := scanOAuthClient(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar cliente oauth: %w", err)
+	}
+	return client, nil
+}
+
+func (r *Repository) ListOAuthClients() ([]OAuthClient, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, COALESCE(secret_hash, ''), redirect_uris, scopes, grant_types,
+		       COALESCE(created_by::text, ''), is_active, created_at
+		FROM oauth_clients
+		WHERE is_active = true
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar clientes oauth: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler cliente oauth: %w", err)
+		}
+		clients = append(clients, *client)
+	}
+
+	return clients, rows.Err()
+}
+
+// DeleteOAuthClient remove o cliente; consentimentos e sessões dele
+// caem junto (ON DELETE CASCADE).
+func (r *Repository) DeleteOAuthClient(clientID string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM oauth_clients WHERE id = $1`, clientID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover cliente oauth: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// scanner cobre *sql.Row e *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOAuthClient(row scanner) (*OAuthClient, error) {
+	var client OAuthClient
+
+	err := row.Scan(
+		&client.ID, &client.Name, &client.SecretHash,
+		pq.Array(&client.RedirectURIs), pq.Array(&client.Scopes), pq.Array(&client.GrantTypes),
+		&client.CreatedBy, &client.IsActive, &client.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// FindConsentScopes retorna os escopos já consentidos (nil se nunca houve).
+func (r *Repository) FindConsentScopes(userID, clientID string) ([]string, error) {
+	var scopes []string
+	err := r.db.QueryRow(`
+		SELECT scopes FROM oauth_consents WHERE user_id = $1 AND client_id = $2
+	`, userID, clientID).Scan(pq.Array(&scopes))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar consentimento: %w", err)
+	}
+	return scopes, nil
+}
+
+// SaveConsent grava (ou amplia) o consentimento do usuário ao cliente.
+func (r *Repository) SaveConsent(userID, clientID string, scopes []string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO oauth_consents (user_id, client_id, scopes)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, client_id)
+		DO UPDATE SET scopes = EXCLUDED.scopes, updated_at = CURRENT_TIMESTAMP
+	`, userID, clientID, pq.Array(scopes))
+	if err != nil {
+		return fmt.Errorf("erro ao salvar consentimento: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListConsents(userID string) ([]ConsentResponse, error) {
+	rows, err := r.db.Query(`
+		SELECT c.client_id, oc.name, c.scopes, c.granted_at
+		FROM oauth_consents c
+		JOIN oauth_clients oc ON oc.id = c.client_id
+		WHERE c.user_id = $1
+		ORDER BY c.granted_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar consentimentos: %w", err)
+	}
+	defer rows.Close()
+
+	var consents []ConsentResponse
+	for rows.Next() {
+		var consent ConsentResponse
+		if err := rows.Scan(&consent.ClientID, &consent.ClientName, pq.Array(&consent.Scopes), &consent.GrantedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler consentimento: %w", err)
+		}
+		consents = append(consents, consent)
+	}
+
+	return consents, rows.Err()
+}
+
+func (r *Repository) DeleteConsent(userID, clientID string) (bool, error) {
+	result, err := r.db.Exec(
+		`DELETE FROM oauth_consents WHERE user_id = $1 AND client_id = $2`,
+		userID, clientID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar consentimento: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// RevokeClientSessions revoga as sessões abertas por um cliente OAuth
+// (de um usuário, ou de todos se userID for vazio) e devolve os IDs.
+func (r *Repository) RevokeClientSessions(clientID, userID, reason string) ([]string, error) {
+	rows, err := r.db.Query(`
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $3
+		WHERE client_id = $1 AND ($2 = '' OR user_id::text = $2) AND revoked_at IS NULL
+		RETURNING id
+	`, clientID, userID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao revogar sessões do cliente: %w", err)
+	}
+	defer rows.Close()
+
+	var sessionIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+
+	return sessionIDs, rows.Err()
+}
@@ -0,0 +1,617 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Escopos com significado para o OIDC. Os demais escopos são livres e
+// ficam a critério dos serviços que validam nossos tokens.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+func newOAuthError(status int, code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description, status: status}
+}
+
+// Status é o HTTP status do erro (400 por padrão, 401 para invalid_client).
+func (e *OAuthError) Status() int {
+	if e.status == 0 {
+		return http.StatusBadRequest
+	}
+	return e.status
+}
+
+// ============================================
+// CLIENTES (admin)
+// ============================================
+
+func (s *Service) CreateOAuthClient(adminID string, req CreateOAuthClientRequest) (*OAuthClientResponse, error) {
+	grants := slices.Compact(slices.Sorted(slices.Values(req.GrantTypes)))
+
+	if slices.Contains(grants, GrantClientCredentials) && !req.Confidential {
+		return nil, errors.New("client_credentials exige cliente confidencial")
+	}
+	if slices.Contains(grants, GrantAuthorizationCode) && len(req.RedirectURIs) == 0 {
+		return nil, errors.New("authorization_code exige ao menos uma redirect_uri")
+	}
+	if slices.Contains(grants, GrantRefreshToken) && !slices.Contains(grants, GrantAuthorizationCode) {
+		return nil, errors.New("refresh_token exige authorization_code")
+	}
+
+	client := OAuthClient{
+		ID:           utils.GenerateSnowflakeID(),
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		GrantTypes:   grants,
+		CreatedBy:    adminID,
+		IsActive:     true,
+		CreatedAt:    time.Now(),
+	}
+	if client.RedirectURIs == nil {
+		client.RedirectURIs = []string{}
+	}
+
+	secret := ""
+	if req.Confidential {
+		var err error
+		secret, err = utils.GenerateRefreshToken()
+		if err != nil {
+			return nil, err
+		}
+		client.SecretHash = utils.HashToken(secret)
+	}
+
+	if err := s.repo.CreateOAuthClient(client); err != nil {
+		return nil, err
+	}
+
+	response := toOAuthClientResponse(client)
+	response.ClientSecret = secret
+	return &response, nil
+}
+
+func (s *Service) ListOAuthClients() ([]OAuthClientResponse, error) {
+	clients, err := s.repo.ListOAuthClients()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		response = append(response, toOAuthClientResponse(client))
+	}
+	return response, nil
+}
+
+// DeleteOAuthClient remove o cliente e derruba os tokens que ele emitiu.
+func (s *Service) DeleteOAuthClient(clientID string) error {
+	if err := s.revokeClientSessions(clientID, "", RevokedClientDeleted); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteOAuthClient(clientID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("cliente não encontrado")
+	}
+	return nil
+}
+
+func toOAuthClientResponse(client OAuthClient) OAuthClientResponse {
+	return OAuthClientResponse{
+		ClientID:     client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		GrantTypes:   client.GrantTypes,
+		Confidential: !client.IsPublic(),
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+// ============================================
+// AUTORIZAÇÃO (authorization code + PKCE)
+// ============================================
+
+// AuthorizeInfo valida o pedido e diz à tela de consentimento o que
+// mostrar. ConsentGiven permite pular a tela quando nada mudou.
+func (s *Service) AuthorizeInfo(userID string, req AuthorizeRequest) (*AuthorizeInfoResponse, error) {
+	client, scopes, err := s.validateAuthorizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	consented, err := s.repo.FindConsentScopes(userID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorizeInfoResponse{
+		ClientName:   client.Name,
+		Scopes:       scopes,
+		ConsentGiven: isSubset(scopes, consented),
+	}, nil
+}
+
+// Authorize registra a decisão do usuário e devolve a URL de retorno ao
+// cliente, com o code (aprovado) ou error=access_denied (negado).
+func (s *Service) Authorize(userID string, req AuthorizeRequest) (*AuthorizeResponse, error) {
+	client, scopes, err := s.validateAuthorizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	redirect, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return nil, errors.New("redirect_uri inválida")
+	}
+	query := redirect.Query()
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+
+	if !req.Approve {
+		query.Set("error", "access_denied")
+		redirect.RawQuery = query.Encode()
+		return &AuthorizeResponse{RedirectTo: redirect.String()}, nil
+	}
+
+	consented, err := s.repo.FindConsentScopes(userID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	merged := slices.Compact(slices.Sorted(slices.Values(append(consented, scopes...))))
+	if err := s.repo.SaveConsent(userID, client.ID, merged); err != nil {
+		return nil, err
+	}
+
+	code, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(oauthCode{
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("oauth_code:%s", utils.HashToken(code))
+	if err := s.redis.Set(ctx, key, payload, s.oauthCodeExpiry).Err(); err != nil {
+		return nil, fmt.Errorf("erro ao salvar authorization code: %w", err)
+	}
+
+	query.Set("code", code)
+	redirect.RawQuery = query.Encode()
+	return &AuthorizeResponse{RedirectTo: redirect.String()}, nil
+}
+
+// validateAuthorizeRequest confere cliente, redirect_uri (comparação
+// exata) e escopos. Sem scope, vale tudo que o cliente pode pedir.
+func (s *Service) validateAuthorizeRequest(req AuthorizeRequest) (*OAuthClient, []string, error) {
+	client, err := s.repo.FindOAuthClient(req.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if client == nil {
+		return nil, nil, errors.New("cliente inválido")
+	}
+	if !slices.Contains(client.GrantTypes, GrantAuthorizationCode) {
+		return nil, nil, errors.New("cliente não autorizado para authorization_code")
+	}
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return nil, nil, errors.New("redirect_uri não registrada para o cliente")
+	}
+
+	scopes, err := resolveScopes(req.Scope, client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, scopes, nil
+}
+
+// ============================================
+// TOKEN
+// ============================================
+
+// Token implementa POST /auth/oauth/token. Erros saem sempre como
+// *OAuthError, no formato da RFC 6749.
+func (s *Service) Token(req TokenRequest, clientInfo ClientInfo) (*TokenResponse, error) {
+	client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials:
+		if !slices.Contains(client.GrantTypes, req.GrantType) {
+			return nil, newOAuthError(http.StatusBadRequest, "unauthorized_client", "grant não permitido para o cliente")
+		}
+	default:
+		return nil, newOAuthError(http.StatusBadRequest, "unsupported_grant_type", "")
+	}
+
+	switch req.GrantType {
+	case GrantAuthorizationCode:
+		return s.exchangeAuthorizationCode(client, req, clientInfo)
+	case GrantRefreshToken:
+		return s.refreshOAuthToken(client, req, clientInfo)
+	default:
+		return s.clientCredentials(client, req)
+	}
+}
+
+// authenticateClient: clientes públicos se identificam só pelo client_id
+// (a prova fica com o PKCE); confidenciais precisam do segredo.
+func (s *Service) authenticateClient(clientID, secret string) (*OAuthClient, error) {
+	invalid := newOAuthError(http.StatusUnauthorized, "invalid_client", "cliente inválido")
+
+	if clientID == "" {
+		return nil, invalid
+	}
+
+	client, err := s.repo.FindOAuthClient(clientID)
+	if err != nil {
+		log.Printf("❌ Erro ao buscar cliente oauth: %v", err)
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	if client == nil {
+		return nil, invalid
+	}
+
+	if client.IsPublic() {
+		if secret != "" {
+			return nil, invalid
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, invalid
+	}
+	return client, nil
+}
+
+func (s *Service) exchangeAuthorizationCode(client *OAuthClient, req TokenRequest, clientInfo ClientInfo) (*TokenResponse, error) {
+	invalidGrant := newOAuthError(http.StatusBadRequest, "invalid_grant", "code inválido ou expirado")
+
+	ctx := context.Background()
+	payload, err := s.redis.GetDel(ctx, fmt.Sprintf("oauth_code:%s", utils.HashToken(req.Code))).Result()
+	if err != nil {
+		return nil, invalidGrant
+	}
+
+	var code oauthCode
+	if err := json.Unmarshal([]byte(payload), &code); err != nil {
+		return nil, invalidGrant
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+		return nil, invalidGrant
+	}
+
+	sum := sha256.Sum256([]byte(req.CodeVerifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if req.CodeVerifier == "" || subtle.ConstantTimeCompare([]byte(challenge), []byte(code.CodeChallenge)) != 1 {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "code_verifier não confere")
+	}
+
+	user, err := s.repo.FindUserByID(code.UserID)
+	if err != nil || user == nil || !user.IsActive {
+		return nil, invalidGrant
+	}
+
+	refreshToken := ""
+	sessionID := ""
+	if slices.Contains(client.GrantTypes, GrantRefreshToken) {
+		refreshToken, err = utils.GenerateRefreshToken()
+		if err != nil {
+			return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+		}
+
+		session := Session{
+			ID:        utils.GenerateSnowflakeID(),
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(refreshToken),
+			UserAgent: clientInfo.UserAgent,
+			IPAddress: clientInfo.IPAddress,
+			ExpiresAt: time.Now().Add(s.refreshExpiry),
+			ClientID:  client.ID,
+			Scope:     code.Scope,
+		}
+		if err := s.repo.CreateSession(session); err != nil {
+			log.Printf("❌ %v", err)
+			return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+		}
+		sessionID = session.ID
+	}
+
+	return s.buildTokenResponse(client, user, code.Scope, code.Nonce, code.AuthTime, sessionID, refreshToken)
+}
+
+// refreshOAuthToken reaproveita a rotação e a detecção de reuso das
+// sessões. O escopo pode ser reduzido, nunca ampliado.
+func (s *Service) refreshOAuthToken(client *OAuthClient, req TokenRequest, clientInfo ClientInfo) (*TokenResponse, error) {
+	invalidGrant := newOAuthError(http.StatusBadRequest, "invalid_grant", "refresh token inválido")
+
+	tokenHash := utils.HashToken(req.RefreshToken)
+	session, _, err := s.repo.FindActiveSessionByTokenHash(tokenHash)
+	if err != nil {
+		return nil, invalidGrant
+	}
+	if session == nil {
+		s.detectReuse(tokenHash)
+		return nil, invalidGrant
+	}
+	if session.ClientID != client.ID {
+		return nil, invalidGrant
+	}
+
+	scope := session.Scope
+	if req.Scope != "" {
+		requested := strings.Fields(req.Scope)
+		if !isSubset(requested, strings.Fields(session.Scope)) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", "escopo maior que o concedido")
+		}
+		scope = strings.Join(requested, " ")
+	}
+
+	user, err := s.repo.FindUserByID(session.UserID)
+	if err != nil || user == nil || !user.IsActive {
+		return nil, invalidGrant
+	}
+
+	newRefreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+
+	rotated, err := s.repo.RotateSession(
+		session.ID, tokenHash, utils.HashToken(newRefreshToken),
+		clientInfo.UserAgent, clientInfo.IPAddress, time.Now().Add(s.refreshExpiry),
+	)
+	if err != nil || !rotated {
+		return nil, invalidGrant
+	}
+
+	return s.buildTokenResponse(client, user, scope, "", 0, session.ID, newRefreshToken)
+}
+
+// clientCredentials emite um token de máquina: sem usuário, sem refresh.
+func (s *Service) clientCredentials(client *OAuthClient, req TokenRequest) (*TokenResponse, error) {
+	if client.IsPublic() {
+		return nil, newOAuthError(http.StatusBadRequest, "unauthorized_client", "cliente público")
+	}
+
+	scopes, err := resolveScopes(req.Scope, client)
+	if err != nil {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", err.Error())
+	}
+	scope := strings.Join(scopes, " ")
+
+	accessToken, err := utils.GenerateJWT(utils.TokenClaims{
+		ClientID: client.ID,
+		Scope:    scope,
+	}, s.keys, s.accessExpiry)
+	if err != nil {
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.accessExpiry.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+func (s *Service) buildTokenResponse(client *OAuthClient, user *models.User, scope, nonce string, authTime int64, sessionID, refreshToken string) (*TokenResponse, error) {
+	accessToken, err := utils.GenerateJWT(utils.TokenClaims{
+		UserID:       user.ID,
+		Email:        user.Email,
+		RoleID:       user.RoleID,
+		SessionID:    sessionID,
+		TokenVersion: user.TokenVersion,
+		ClientID:     client.ID,
+		Scope:        scope,
+	}, s.keys, s.accessExpiry)
+	if err != nil {
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+
+	response := &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessExpiry.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}
+
+	if hasScope(scope, ScopeOpenID) {
+		response.IDToken, err = s.generateIDToken(client, user, scope, nonce, authTime)
+		if err != nil {
+			return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+		}
+	}
+
+	return response, nil
+}
+
+// generateIDToken assina o id_token com as mesmas chaves dos access
+// tokens. No modo HS256 legado o cliente não tem como validá-lo: use
+// JWT_SIGNING_KEY ao expor o servidor OAuth.
+func (s *Service) generateIDToken(client *OAuthClient, user *models.User, scope, nonce string, authTime int64) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": s.keys.Issuer(),
+		"sub": user.ID,
+		"aud": client.ID,
+		"iat": now.Unix(),
+		"exp": now.Add(s.accessExpiry).Unix(),
+	}
+	if authTime != 0 {
+		claims["auth_time"] = authTime
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if hasScope(scope, ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.IsEmailVerified
+	}
+	if hasScope(scope, ScopeProfile) {
+		claims["name"] = user.Name
+	}
+
+	return s.keys.Sign(claims)
+}
+
+// ============================================
+// USERINFO / CONSENTIMENTOS / DISCOVERY
+// ============================================
+
+// UserInfo devolve os dados do usuário liberados pelos escopos do token.
+func (s *Service) UserInfo(claims *utils.TokenClaims) (*UserInfoResponse, error) {
+	if !hasScope(claims.Scope, ScopeOpenID) {
+		return nil, errors.New("token sem escopo openid")
+	}
+
+	user, err := s.repo.FindUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	response := &UserInfoResponse{Sub: user.ID}
+	if hasScope(claims.Scope, ScopeEmail) {
+		verified := user.IsEmailVerified
+		response.Email = user.Email
+		response.EmailVerified = &verified
+	}
+	if hasScope(claims.Scope, ScopeProfile) {
+		response.Name = user.Name
+	}
+	return response, nil
+}
+
+func (s *Service) ListConsents(userID string) ([]ConsentResponse, error) {
+	consents, err := s.repo.ListConsents(userID)
+	if err != nil {
+		return nil, err
+	}
+	if consents == nil {
+		consents = []ConsentResponse{}
+	}
+	return consents, nil
+}
+
+// RevokeConsent retira o acesso do cliente: o consentimento some e as
+// sessões (refresh tokens) que ele tinha para o usuário são revogadas.
+func (s *Service) RevokeConsent(userID, clientID string) error {
+	deleted, err := s.repo.DeleteConsent(userID, clientID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("consentimento não encontrado")
+	}
+
+	return s.revokeClientSessions(clientID, userID, RevokedConsent)
+}
+
+func (s *Service) revokeClientSessions(clientID, userID, reason string) error {
+	sessionIDs, err := s.repo.RevokeClientSessions(clientID, userID, reason)
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		if err := s.blacklistSession(sessionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenIDConfiguration monta o documento de discovery. O
+// authorization_endpoint é a tela de consentimento do frontend, que
+// chama /auth/oauth/authorize com a sessão do usuário.
+func (s *Service) OpenIDConfiguration() map[string]any {
+	return map[string]any{
+		"issuer":                                s.keys.Issuer(),
+		"authorization_endpoint":                s.oauthAuthorizeURL,
+		"token_endpoint":                        s.publicURL + "/auth/oauth/token",
+		"userinfo_endpoint":                     s.publicURL + "/auth/oauth/userinfo",
+		"jwks_uri":                              s.publicURL + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": s.keys.ValidMethods(),
+		"scopes_supported":                      []string{ScopeOpenID, ScopeProfile, ScopeEmail},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified", "name"},
+	}
+}
+
+// resolveScopes valida os escopos pedidos contra os do cliente.
+func resolveScopes(scope string, client *OAuthClient) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+
+	for _, s := range requested {
+		if !slices.Contains(client.Scopes, s) {
+			return nil, fmt.Errorf("escopo não permitido para o cliente: %s", s)
+		}
+	}
+	return slices.Compact(slices.Sorted(slices.Values(requested))), nil
+}
+
+func hasScope(scope, wanted string) bool {
+	return slices.Contains(strings.Fields(scope), wanted)
+}
+
+func isSubset(items, set []string) bool {
+	for _, item := range items {
+		if !slices.Contains(set, item) {
+			return false
+		}
+	}
+	return true
+}
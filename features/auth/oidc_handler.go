@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListOIDCProviders
+// @Summary Provedores de login social
+// @Tags auth
+// @Produce json
+// @Success 200 {object} Response{data=[]string}
+// @Router /auth/oidc/providers [get]
+func (h *Handler) ListOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: h.service.OIDCProviders()})
+}
+
+// StartOIDCLogin
+// @Summary Iniciar login social
+// @Description Retorna a URL do provedor para onde o usuário deve ser redirecionado.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Nome do provedor (ex: google)"
+// @Success 200 {object} Response{data=AuthorizationURLResponse}
+// @Failure 404 {object} Response
+// @Router /auth/oidc/{provider}/start [post]
+func (h *Handler) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	h.startOIDC(w, chi.URLParam(r, "provider"), "")
+}
+
+// OIDCCallback
+// @Summary Concluir login social
+// @Description Recebe o code/state devolvidos pelo provedor e faz o login (criando a conta no primeiro acesso).
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body OIDCCallbackRequest true "code e state"
+// @Success 200 {object} Response{data=LoginResponse}
+// @Failure 401 {object} Response
+// @Router /auth/oidc/callback [post]
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	var req OIDCCallbackRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.service.LoginWithOIDC(req.Code, req.State, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	writeLoginResponse(w, response)
+}
+
+// ListIdentities
+// @Summary Provedores vinculados
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]IdentityResponse}
+// @Router /auth/identities [get]
+func (h *Handler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := h.service.ListIdentities(claims.UserID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar provedores"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: identities})
+}
+
+// StartLinkIdentity
+// @Summary Iniciar vínculo de provedor
+// @Description Retorna a URL do provedor; o retorno é concluído em POST /auth/identities/callback.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Nome do provedor (ex: google)"
+// @Success 200 {object} Response{data=AuthorizationURLResponse}
+// @Router /auth/identities/{provider} [post]
+func (h *Handler) StartLinkIdentity(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.startOIDC(w, chi.URLParam(r, "provider"), claims.UserID)
+}
+
+// LinkIdentityCallback
+// @Summary Concluir vínculo de provedor
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body OIDCCallbackRequest true "code e state"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Router /auth/identities/callback [post]
+func (h *Handler) LinkIdentityCallback(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.LinkIdentity(claims.UserID, req.Code, req.State); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "provedor vinculado"})
+}
+
+// UnlinkIdentity
+// @Summary Desvincular provedor
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Nome do provedor"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /auth/identities/{provider} [delete]
+func (h *Handler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.UnlinkIdentity(claims.UserID, chi.URLParam(r, "provider")); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "provedor desvinculado"})
+}
+
+func (h *Handler) startOIDC(w http.ResponseWriter, provider, linkUserID string) {
+	response, err := h.service.StartOIDC(provider, linkUserID)
+	if err != nil {
+		status := http.StatusServiceUnavailable
+		if err.Error() == "provedor não suportado" {
+			status = http.StatusNotFound
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: response})
+}
@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/features/users"
+	"loginbackend/pkg/oidc"
+	"loginbackend/pkg/utils"
+)
+
+// UserProvisioner cria a conta local no primeiro login social
+// (implementado por users.Service).
+type UserProvisioner interface {
+	Create(req users.CreateUserRequest) (*models.User, error)
+}
+
+// SetUserProvisioner injeta users.Service depois da construção — users
+// depende de auth (verificação de email, sessões), então não dá para
+// recebê-lo no NewService.
+func (s *Service) SetUserProvisioner(provisioner UserProvisioner) {
+	s.users = provisioner
+}
+
+// OIDCProviders lista os nomes dos provedores configurados.
+func (s *Service) OIDCProviders() []string {
+	names := make([]string, 0, len(s.oidcProviders))
+	for name := range s.oidcProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartOIDC inicia o fluxo authorization code + PKCE. state, nonce e
+// code_verifier ficam no Redis até o callback (uso único).
+// linkUserID vazio = login; preenchido = vínculo à conta logada.
+func (s *Service) StartOIDC(providerName, linkUserID string) (*AuthorizationURLResponse, error) {
+	provider, ok := s.oidcProviders[providerName]
+	if !ok {
+		return nil, errors.New("provedor não suportado")
+	}
+
+	state, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, challenge, err := oidc.GeneratePKCE()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(oidcState{
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("oidc_state:%s", utils.HashToken(state))
+	if err := s.redis.Set(ctx, key, payload, s.oidcStateExpiry).Err(); err != nil {
+		return nil, fmt.Errorf("erro ao salvar state: %w", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, s.oidcRedirectURL, state, nonce, challenge)
+	if err != nil {
+		log.Printf("❌ Erro no discovery do provedor %s: %v", providerName, err)
+		return nil, errors.New("provedor indisponível")
+	}
+
+	return &AuthorizationURLResponse{AuthorizationURL: authURL}, nil
+}
+
+// LoginWithOIDC conclui o login social. Identidade já vinculada entra
+// direto; identidade nova cria a conta (just-in-time) se o email ainda
+// não existir localmente.
+func (s *Service) LoginWithOIDC(code, state string, client ClientInfo) (*LoginResponse, error) {
+	st, claims, err := s.completeOIDC(code, state)
+	if err != nil {
+		return nil, err
+	}
+	if st.LinkUserID != "" {
+		return nil, errors.New("state inválido")
+	}
+
+	user, err := s.repo.FindUserByIdentity(st.Provider, claims.Subject)
+	if err != nil {
+		return nil, errors.New("erro ao buscar usuário")
+	}
+	if user == nil {
+		user, err = s.provisionOIDCUser(st.Provider, claims)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := s.repo.TouchIdentity(st.Provider, claims.Subject); err != nil {
+		log.Printf("⚠️ Erro ao atualizar identidade: %v", err)
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("usuário inativo")
+	}
+	if err := s.checkLockout(user); err != nil {
+		return nil, err
+	}
+	// Vale também para identidades já vinculadas: o email local pode ter
+	// sido trocado (e não verificado) depois do vínculo.
+	if s.requireVerifiedEmail && !user.IsEmailVerified {
+		return nil, errors.New("email não verificado. Verifique sua caixa de entrada")
+	}
+
+	// O provedor substitui só a senha: o segundo fator continua valendo.
+	methods, err := s.mfaMethods(user)
+	if err != nil {
+		return nil, errors.New("erro ao buscar usuário")
+	}
+	if len(methods) > 0 {
+		return s.createMFAChallenge(user.ID, methods)
+	}
+
+	return s.issueTokens(user, client)
+}
+
+// provisionOIDCUser cria a conta no primeiro login. Um email que já
+// existe localmente NÃO é vinculado automaticamente: o dono da conta
+// precisa entrar com a senha e vincular o provedor (evita tomada de
+// conta por quem controla o email em outro provedor).
+func (s *Service) provisionOIDCUser(provider string, claims *oidc.Claims) (*models.User, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.New("o provedor não informou um email verificado")
+	}
+
+	existing, err := s.repo.FindUserByEmail(claims.Email)
+	if err != nil {
+		return nil, errors.New("erro ao buscar usuário")
+	}
+	if existing != nil {
+		return nil, errors.New("já existe uma conta com este email. Entre com sua senha e vincule o provedor")
+	}
+
+	// Senha aleatória e descartada: a conta entra pelo provedor e, se
+	// quiser senha, usa "esqueci minha senha".
+	password, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.users.Create(users.CreateUserRequest{
+		Name:              oidcDisplayName(claims),
+		Email:             claims.Email,
+		Password:          password,
+		EmailVerified:     true,
+		GeneratedPassword: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sem o vínculo a conta ficaria órfã (sem senha conhecida) e
+	// bloquearia o email para sempre: desfaz o cadastro.
+	if err := s.repo.CreateIdentity(Identity{
+		ID:       utils.GenerateSnowflakeID(),
+		UserID:   created.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		if deleteErr := s.repo.DeleteUser(created.ID); deleteErr != nil {
+			log.Printf("❌ Erro ao desfazer cadastro de %s após falha no vínculo: %v", created.ID, deleteErr)
+		}
+		return nil, err
+	}
+
+	log.Printf("👤 Usuário criado via %s: %s", provider, created.ID)
+
+	return s.repo.FindUserByID(created.ID)
+}
+
+// oidcDisplayName respeita a validação de users (3 a 100 caracteres).
+func oidcDisplayName(claims *oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if utf8.RuneCountInString(name) < 3 {
+		name = claims.Email
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return name
+}
+
+// LinkIdentity conclui o vínculo iniciado por StartOIDC com linkUserID.
+// O state precisa ter sido gerado para o mesmo usuário que conclui.
+func (s *Service) LinkIdentity(userID, code, state string) error {
+	st, claims, err := s.completeOIDC(code, state)
+	if err != nil {
+		return err
+	}
+	if st.LinkUserID != userID {
+		return errors.New("state inválido")
+	}
+
+	owner, err := s.repo.FindUserByIdentity(st.Provider, claims.Subject)
+	if err != nil {
+		return err
+	}
+	if owner != nil {
+		if owner.ID == userID {
+			return nil
+		}
+		return errors.New("esta conta do provedor já está vinculada a outro usuário")
+	}
+
+	existing, err := s.repo.FindIdentity(userID, st.Provider)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors.New("provedor já vinculado. Desvincule antes de trocar de conta")
+	}
+
+	return s.repo.CreateIdentity(Identity{
+		ID:       utils.GenerateSnowflakeID(),
+		UserID:   userID,
+		Provider: st.Provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+}
+
+func (s *Service) ListIdentities(userID string) ([]IdentityResponse, error) {
+	identities, err := s.repo.ListIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		response = append(response, IdentityResponse{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	return response, nil
+}
+
+func (s *Service) UnlinkIdentity(userID, provider string) error {
+	deleted, err := s.repo.DeleteIdentity(userID, provider)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("provedor não vinculado")
+	}
+	return nil
+}
+
+// completeOIDC consome o state (uso único), troca o code e valida o
+// id_token com o nonce guardado.
+func (s *Service) completeOIDC(code, state string) (*oidcState, *oidc.Claims, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("oidc_state:%s", utils.HashToken(state))
+
+	payload, err := s.redis.GetDel(ctx, key).Result()
+	if err != nil {
+		return nil, nil, errors.New("state inválido ou expirado")
+	}
+
+	var st oidcState
+	if err := json.Unmarshal([]byte(payload), &st); err != nil {
+		return nil, nil, errors.New("state inválido ou expirado")
+	}
+
+	provider, ok := s.oidcProviders[st.Provider]
+	if !ok {
+		return nil, nil, errors.New("provedor não suportado")
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, st.CodeVerifier, s.oidcRedirectURL)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return nil, nil, errors.New("falha ao autenticar com o provedor")
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, st.Nonce)
+	if err != nil {
+		log.Printf("❌ Provedor %s: %v", st.Provider, err)
+		return nil, nil, errors.New("falha ao autenticar com o provedor")
+	}
+
+	return &st, claims, nil
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/pkg/mailer"
+	"loginbackend/pkg/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ForgotPassword envia o link de redefinição de senha. Nunca informa se
+// o email existe: para quem chama, a resposta é sempre a mesma.
+func (s *Service) ForgotPassword(email string) error {
+	user, err := s.repo.FindUserByEmail(email)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return nil
+	}
+
+	// Falhas daqui em diante só existem para contas cadastradas: viram log,
+	// senão o 500 denunciaria que o email existe.
+	if err := s.sendPasswordReset(user); err != nil {
+		log.Printf("❌ Erro ao enviar email de reset para %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// sendPasswordReset grava o token novo e envia o link. O link anterior só
+// é invalidado depois que o novo saiu: falha no envio não deixa o usuário
+// sem nenhum link válido.
+func (s *Service) sendPasswordReset(user *models.User) error {
+	ctx := context.Background()
+
+	// Um pedido por minuto por conta — evita usar o endpoint para
+	// inundar a caixa de entrada de alguém.
+	throttleKey := fmt.Sprintf("password_reset_throttle:%s", user.ID)
+	fresh, err := s.redis.SetNX(ctx, throttleKey, "1", passwordResetThrottle).Result()
+	if err != nil {
+		return fmt.Errorf("erro ao registrar pedido de reset: %w", err)
+	}
+	if !fresh {
+		return nil
+	}
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return fmt.Errorf("erro ao gerar token de reset: %w", err)
+	}
+	tokenHash := utils.HashToken(token)
+
+	resetKey := fmt.Sprintf("password_reset:%s", tokenHash)
+	if err := s.redis.Set(ctx, resetKey, user.ID, s.passwordResetExpiry).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar token de reset: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(s.appURL, "/"), token)
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Redefinição de senha",
+		Body: fmt.Sprintf(
+			"Olá, %s.\n\nRecebemos um pedido para redefinir a sua senha. Use o link abaixo (válido por %d minutos):\n\n%s\n\nSe não foi você, ignore este email — sua senha continua a mesma.",
+			user.Name, int(s.passwordResetExpiry.Minutes()), link,
+		),
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		s.redis.Del(ctx, resetKey)
+		return fmt.Errorf("erro ao enviar email: %w", err)
+	}
+
+	// Só o último link enviado vale: apaga o token anterior, se houver.
+	userKey := fmt.Sprintf("password_reset_user:%s", user.ID)
+	if previous, err := s.redis.Get(ctx, userKey).Result(); err == nil {
+		s.redis.Del(ctx, fmt.Sprintf("password_reset:%s", previous))
+	}
+	if err := s.redis.Set(ctx, userKey, tokenHash, s.passwordResetExpiry).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar token de reset: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword troca a senha usando o token recebido por email.
+// O token é de uso único (GETDEL) e, após a troca, todas as sessões
+// do usuário são encerradas. Uma senha recusada pela política não
+// consome o token: o usuário tenta outra com o mesmo link.
+func (s *Service) ResetPassword(token, newPassword string) error {
+	ctx := context.Background()
+	tokenHash := utils.HashToken(token)
+	resetKey := fmt.Sprintf("password_reset:%s", tokenHash)
+
+	userID, err := s.redis.Get(ctx, resetKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return errors.New("link de redefinição inválido ou expirado")
+		}
+		return fmt.Errorf("erro ao ler token de reset: %w", err)
+	}
+
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil || !user.IsActive {
+		return errors.New("link de redefinição inválido ou expirado")
+	}
+
+	if err := s.checkNewPassword(user, newPassword); err != nil {
+		return err
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// Consome o token só agora; quem chegar depois com o mesmo link
+	// (requisição concorrente) perde a corrida.
+	if consumed, err := s.redis.GetDel(ctx, resetKey).Result(); err != nil || consumed != userID {
+		return errors.New("link de redefinição inválido ou expirado")
+	}
+	s.redis.Del(ctx, fmt.Sprintf("password_reset_user:%s", userID))
+
+	if err := s.repo.UpdatePassword(userID, hash); err != nil {
+		return err
+	}
+
+	// Link de definição de senha (SendAccountSetup) chegou pelo email da
+	// conta: vale também como verificação, se o email não mudou desde o envio.
+	if email, err := s.redis.GetDel(ctx, fmt.Sprintf("account_setup:%s", tokenHash)).Result(); err == nil {
+		if _, err := s.repo.MarkEmailVerified(userID, email); err != nil {
+			log.Printf("⚠️ Erro ao verificar email de %s: %v", userID, err)
+		}
+	}
+
+	return s.RevokeAllSessions(userID, RevokedPasswordReset)
+}
+
+// SendAccountSetup envia o link para o usuário definir a própria senha
+// numa conta criada por um administrador. É um token de reset comum (mesmo
+// ResetPassword), com validade maior; usado pela feature users na importação.
+func (s *Service) SendAccountSetup(userID, email, name string) error {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return fmt.Errorf("erro ao gerar token de convite: %w", err)
+	}
+	tokenHash := utils.HashToken(token)
+
+	ctx := context.Background()
+	userKey := fmt.Sprintf("password_reset_user:%s", userID)
+	if previous, err := s.redis.Get(ctx, userKey).Result(); err == nil {
+		s.redis.Del(ctx, fmt.Sprintf("password_reset:%s", previous))
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf("password_reset:%s", tokenHash), userID, accountSetupExpiry)
+	pipe.Set(ctx, fmt.Sprintf("account_setup:%s", tokenHash), email, accountSetupExpiry)
+	pipe.Set(ctx, userKey, tokenHash, accountSetupExpiry)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("erro ao salvar token de convite: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(s.appURL, "/"), token)
+
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Sua conta foi criada",
+		Body: fmt.Sprintf(
+			"Olá, %s.\n\nUma conta foi criada para você. Defina a sua senha pelo link abaixo (válido por %d dias):\n\n%s\n\nSe você não esperava este convite, ignore este email.",
+			name, int(accountSetupExpiry.Hours()/24), link,
+		),
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		return fmt.Errorf("erro ao enviar email de convite: %w", err)
+	}
+
+	return nil
+}
+
+// checkNewPassword aplica a política (regras, senhas vazadas e histórico)
+// a uma senha nova do usuário.
+func (s *Service) checkNewPassword(user *models.User, newPassword string) error {
+	if err := s.passwordPolicy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	if s.passwordPolicy.HistorySize <= 1 {
+		return s.passwordPolicy.CheckReuse(newPassword, user.PasswordHash, nil)
+	}
+
+	previous, err := s.repo.PasswordHistory(user.ID, s.passwordPolicy.HistorySize-1)
+	if err != nil {
+		return err
+	}
+	return s.passwordPolicy.CheckReuse(newPassword, user.PasswordHash, previous)
+}
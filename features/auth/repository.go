@@ -2,7 +2,9 @@ package auth
 
 import (
 	"database/sql"
+	"fmt"
 	"loginbackend/features/shared/models"
+	"time"
 )
 
 type Repository struct {
@@ -17,13 +19,31 @@ func (r *Repository) FindUserByEmail(email string) (*models.User, error) {
 	row := r.db.QueryRow(`
 		SELECT id, email, name, password_hash, role_id, is_active, 
 			   created_at, updated_at, last_password_update, is_email_verified,
-			   last_login_at, profile_image_url, refresh_token
+			   last_login_at, profile_image_url, refresh_token,
+			   totp_secret, COALESCE(totp_enabled, false), locked_until, token_version
 		FROM users WHERE email = $1
 	`, email)
 
+	return r.scanUser(row)
+}
+
+func (r *Repository) FindUserByID(userID string) (*models.User, error) {
+	row := r.db.QueryRow(`
+		SELECT id, email, name, password_hash, role_id, is_active, 
+			   created_at, updated_at, last_password_update, is_email_verified,
+			   last_login_at, profile_image_url, refresh_token,
+			   totp_secret, COALESCE(totp_enabled, false), locked_until, token_version
+		FROM users WHERE id = $1
+	`, userID)
+
+	return r.scanUser(row)
+}
+
+// scanUser - Helper comum a FindUserByEmail/FindUserByID
+func (r *Repository) scanUser(row *sql.Row) (*models.User, error) {
 	var user models.User
-	var lastLoginAt, profileImageUrl, refreshToken sql.NullString
-	var lastPasswordUpdate sql.NullTime
+	var lastLoginAt, profileImageUrl, refreshToken, totpSecret sql.NullString
+	var lastPasswordUpdate, lockedUntil sql.NullTime
 
 	err := row.Scan(
 		&user.ID,
@@ -39,6 +59,10 @@ func (r *Repository) FindUserByEmail(email string) (*models.User, error) {
 		&lastLoginAt,
 		&profileImageUrl,
 		&refreshToken,
+		&totpSecret,
+		&user.TotpEnabled,
+		&lockedUntil,
+		&user.TokenVersion,
 	)
 
 	if err != nil {
@@ -58,6 +82,12 @@ func (r *Repository) FindUserByEmail(email string) (*models.User, error) {
 	if refreshToken.Valid {
 		user.RefreshToken = &refreshToken.String
 	}
+	if totpSecret.Valid {
+		user.TotpSecret = &totpSecret.String
+	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
 
 	return &user, nil
 }
@@ -70,43 +100,603 @@ func (r *Repository) UpdateLastLogin(userID string) error {
 	return err
 }
 
-func (r *Repository) SaveRefreshToken(userID string, refreshToken string) error {
-	_, err := r.db.Exec(
-		`UPDATE users SET refresh_token = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
-		refreshToken, userID,
-	)
-	return err
+// CreateSession registra um novo dispositivo logado.
+func (r *Repository) CreateSession(session Session) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_sessions (id, user_id, token_hash, user_agent, ip_address, expires_at, client_id, scope)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''))
+	`, session.ID, session.UserID, session.TokenHash, session.UserAgent, session.IPAddress, session.ExpiresAt,
+		session.ClientID, session.Scope)
+	if err != nil {
+		return fmt.Errorf("erro ao criar sessão: %w", err)
+	}
+	return nil
 }
 
-func (r *Repository) FindUserByRefreshToken(refreshToken string) (*models.User, error) {
+// FindActiveSessionByTokenHash busca a sessão (não revogada, não expirada)
+// junto com o usuário dono — que precisa estar ativo.
+func (r *Repository) FindActiveSessionByTokenHash(tokenHash string) (*Session, *models.User, error) {
 	row := r.db.QueryRow(`
-		SELECT id, email, name, role_id, is_active, created_at
-		FROM users WHERE refresh_token = $1 AND is_active = true
-	`, refreshToken)
+		SELECT s.id, s.user_id, s.user_agent, s.ip_address, s.created_at, s.last_used_at, s.expires_at,
+		       COALESCE(s.client_id, ''), COALESCE(s.scope, ''),
+		       u.email, u.name, u.role_id, u.is_active, u.created_at, u.token_version
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = $1
+		  AND s.revoked_at IS NULL
+		  AND s.expires_at > NOW()
+		  AND u.is_active = true
+	`, tokenHash)
 
+	var session Session
 	var user models.User
+	var userAgent, ipAddress sql.NullString
+
 	err := row.Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.RoleID,
-		&user.IsActive,
-		&user.CreatedAt,
+		&session.ID, &session.UserID, &userAgent, &ipAddress,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		&session.ClientID, &session.Scope,
+		&user.Email, &user.Name, &user.RoleID, &user.IsActive, &user.CreatedAt, &user.TokenVersion,
 	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("erro ao buscar sessão: %w", err)
+	}
+
+	session.UserAgent = userAgent.String
+	session.IPAddress = ipAddress.String
+	user.ID = session.UserID
+
+	return &session, &user, nil
+}
+
+// RotateSession troca o token da sessão (rotação a cada refresh) e
+// renova a expiração. A condição no token antigo garante que duas
+// requisições concorrentes com o mesmo token não rotacionem ambas.
+// O hash antigo vai para refresh_token_history para detectar reuso.
+func (r *Repository) RotateSession(sessionID, oldTokenHash, newTokenHash, userAgent, ipAddress string, expiresAt time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE user_sessions
+		SET token_hash = $1, user_agent = $2, ip_address = $3,
+		    last_used_at = CURRENT_TIMESTAMP, expires_at = $4
+		WHERE id = $5 AND token_hash = $6 AND revoked_at IS NULL
+	`, newTokenHash, userAgent, ipAddress, expiresAt, sessionID, oldTokenHash)
+	if err != nil {
+		return false, fmt.Errorf("erro ao rotacionar sessão: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO refresh_token_history (token_hash, session_id) VALUES ($1, $2)`,
+		oldTokenHash, sessionID,
+	); err != nil {
+		return false, fmt.Errorf("erro ao registrar histórico do refresh token: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// FindRotatedToken procura um hash que JÁ foi rotacionado. Retorna nil
+// se o token nunca existiu (ou se a sessão foi limpa).
+func (r *Repository) FindRotatedToken(tokenHash string) (*RotatedToken, error) {
+	var rotated RotatedToken
+	err := r.db.QueryRow(`
+		SELECT h.session_id, s.user_id, h.rotated_at
+		FROM refresh_token_history h
+		JOIN user_sessions s ON s.id = h.session_id
+		WHERE h.token_hash = $1
+	`, tokenHash).Scan(&rotated.SessionID, &rotated.UserID, &rotated.RotatedAt)
+
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
-		return nil, err
+		return nil, fmt.Errorf("erro ao buscar histórico do refresh token: %w", err)
 	}
 
-	return &user, nil
+	return &rotated, nil
+}
+
+// ListActiveSessions lista os dispositivos logados do usuário.
+func (r *Repository) ListActiveSessions(userID string) ([]Session, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, token_hash, user_agent, ip_address, created_at, last_used_at, expires_at,
+		       COALESCE(client_id, '')
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar sessões: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		var userAgent, ipAddress sql.NullString
+
+		if err := rows.Scan(
+			&session.ID, &session.UserID, &session.TokenHash, &userAgent, &ipAddress,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.ClientID,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao scanear sessão: %w", err)
+		}
+
+		session.UserAgent = userAgent.String
+		session.IPAddress = ipAddress.String
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
 }
 
-func (r *Repository) ClearRefreshToken(userID string) error {
+// SessionCreatedAt devolve quando a sessão ativa do usuário foi aberta,
+// ou nil se ela não existe (ou já foi revogada/expirou).
+func (r *Repository) SessionCreatedAt(userID, sessionID string) (*time.Time, error) {
+	var createdAt time.Time
+	err := r.db.QueryRow(`
+		SELECT created_at FROM user_sessions
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+	`, sessionID, userID).Scan(&createdAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar sessão: %w", err)
+	}
+	return &createdAt, nil
+}
+
+// RevokeSessionByTokenHash revoga a sessão dona do token (logout) e
+// retorna o ID dela, ou "" se o token não pertencia a sessão ativa.
+func (r *Repository) RevokeSessionByTokenHash(tokenHash, reason string) (string, error) {
+	var sessionID string
+	err := r.db.QueryRow(`
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $2
+		WHERE token_hash = $1 AND revoked_at IS NULL
+		RETURNING id
+	`, tokenHash, reason).Scan(&sessionID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("erro ao revogar sessão: %w", err)
+	}
+	return sessionID, nil
+}
+
+// RevokeUserSession revoga uma sessão específica, desde que pertença ao
+// usuário. Retorna false se não existir (ou for de outro usuário).
+func (r *Repository) RevokeUserSession(userID, sessionID, reason string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, sessionID, userID, reason)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar sessão: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// RevokeAllUserSessions encerra todos os dispositivos do usuário e
+// retorna os IDs revogados (para invalidar os access tokens deles).
+func (r *Repository) RevokeAllUserSessions(userID, reason string) ([]string, error) {
+	rows, err := r.db.Query(`
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+		RETURNING id
+	`, userID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao revogar sessões: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// DeleteUserPersonalTokens apaga todos os personal access tokens do usuário.
+func (r *Repository) DeleteUserPersonalTokens(userID string) error {
+	if _, err := r.db.Exec(`DELETE FROM personal_access_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("erro ao revogar personal access tokens: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword grava o novo hash e marca a data da troca. O hash que
+// sai vai para o histórico na mesma transação.
+func (r *Repository) UpdatePassword(userID, newHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO password_history (user_id, password_hash)
+		SELECT id, password_hash FROM users WHERE id = $1
+	`, userID); err != nil {
+		return fmt.Errorf("erro ao gravar histórico de senhas: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users 
+		SET password_hash = $1, last_password_update = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, newHash, userID); err != nil {
+		return fmt.Errorf("erro ao atualizar senha: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// UpgradePasswordHash troca o hash da mesma senha por um mais forte. Não
+// é uma troca de senha: last_password_update e o histórico ficam como
+// estão. A condição no hash antigo evita sobrescrever uma troca de senha
+// concorrente.
+func (r *Repository) UpgradePasswordHash(userID, oldHash, newHash string) error {
+	_, err := r.db.Exec(`
+		UPDATE users SET password_hash = $3
+		WHERE id = $1 AND password_hash = $2
+	`, userID, oldHash, newHash)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar hash da senha: %w", err)
+	}
+	return nil
+}
+
+// PasswordHistory devolve os hashes anteriores, do mais recente ao mais antigo.
+func (r *Repository) PasswordHistory(userID string, limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT password_hash FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar histórico de senhas: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+// MarkEmailVerified confirma o email, desde que ele ainda seja o email
+// atual da conta (um link antigo não verifica um email trocado depois).
+func (r *Repository) MarkEmailVerified(userID, email string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE users SET is_email_verified = true, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND email = $2
+	`, userID, email)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar email: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// IncrementTokenVersion invalida todos os access tokens já emitidos
+// para o usuário. Retorna a nova versão.
+func (r *Repository) IncrementTokenVersion(userID string) (int, error) {
+	var version int
+	err := r.db.QueryRow(`
+		UPDATE users SET token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING token_version
+	`, userID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao incrementar versão dos tokens: %w", err)
+	}
+	return version, nil
+}
+
+// LockUser bloqueia o login da conta até o instante informado.
+func (r *Repository) LockUser(userID string, until time.Time) error {
 	_, err := r.db.Exec(
-		`UPDATE users SET refresh_token = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		`UPDATE users SET locked_until = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		until, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao bloquear usuário: %w", err)
+	}
+	return nil
+}
+
+// UnlockUser remove o bloqueio. Retorna false se o usuário não existe.
+func (r *Repository) UnlockUser(userID string) (bool, error) {
+	result, err := r.db.Exec(
+		`UPDATE users SET locked_until = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao desbloquear usuário: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// SaveTotpSecret grava um segredo ainda NÃO confirmado. O TOTP só passa
+// a ser exigido no login depois de EnableTotp.
+func (r *Repository) SaveTotpSecret(userID, secret string) error {
+	_, err := r.db.Exec(
+		`UPDATE users SET totp_secret = $1, totp_enabled = false, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		secret, userID,
+	)
+	return err
+}
+
+// EnableTotp ativa o TOTP e substitui os códigos de recuperação na mesma
+// transação — não pode existir TOTP ativo sem códigos de recuperação.
+func (r *Repository) EnableTotp(userID string, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE users SET totp_enabled = true, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		userID,
+	); err != nil {
+		return fmt.Errorf("erro ao ativar TOTP: %w", err)
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTotp remove o segredo e todos os códigos de recuperação.
+func (r *Repository) DisableTotp(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE users SET totp_secret = NULL, totp_enabled = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		userID,
+	); err != nil {
+		return fmt.Errorf("erro ao desativar TOTP: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("erro ao remover códigos de recuperação: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes invalida os códigos antigos e grava os novos.
+func (r *Repository) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("erro ao remover códigos de recuperação: %w", err)
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(
+			`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, hash,
+		); err != nil {
+			return fmt.Errorf("erro ao salvar código de recuperação: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marca o código como usado de forma atômica.
+// Retorna false se o código não existe ou já foi consumido.
+func (r *Repository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("erro ao consumir código de recuperação: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// CountRecoveryCodes retorna quantos códigos de recuperação ainda não foram usados.
+func (r *Repository) CountRecoveryCodes(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`,
 		userID,
+	).Scan(&count)
+	return count, err
+}
+
+// FindUserByIdentity busca o usuário local vinculado a uma identidade externa.
+func (r *Repository) FindUserByIdentity(provider, subject string) (*models.User, error) {
+	row := r.db.QueryRow(`
+		SELECT u.id, u.email, u.name, u.password_hash, u.role_id, u.is_active, 
+			   u.created_at, u.updated_at, u.last_password_update, u.is_email_verified,
+			   u.last_login_at, u.profile_image_url, u.refresh_token,
+			   u.totp_secret, COALESCE(u.totp_enabled, false), u.locked_until, u.token_version
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.provider = $1 AND i.subject = $2
+	`, provider, subject)
+
+	user, err := r.scanUser(row)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar identidade: %w", err)
+	}
+	return user, nil
+}
+
+// FindIdentity retorna a identidade do usuário para o provedor, se houver.
+func (r *Repository) FindIdentity(userID, provider string) (*Identity, error) {
+	var identity Identity
+	var email sql.NullString
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities
+		WHERE user_id = $1 AND provider = $2
+	`, userID, provider).Scan(
+		&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &email, &identity.CreatedAt,
 	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar identidade: %w", err)
+	}
+
+	identity.Email = email.String
+	return &identity, nil
+}
+
+func (r *Repository) CreateIdentity(identity Identity) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_identities (id, user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), CURRENT_TIMESTAMP)
+	`, identity.ID, identity.UserID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return fmt.Errorf("erro ao vincular identidade: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser apaga de vez uma conta recém-criada. Só desfaz o cadastro
+// just-in-time do login social quando o vínculo falha — contas em uso são
+// desativadas/anonimizadas por users, nunca apagadas.
+func (r *Repository) DeleteUser(userID string) error {
+	if _, err := r.db.Exec(`DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("erro ao apagar usuário: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) TouchIdentity(provider, subject string) error {
+	_, err := r.db.Exec(`
+		UPDATE user_identities SET last_login_at = CURRENT_TIMESTAMP
+		WHERE provider = $1 AND subject = $2
+	`, provider, subject)
 	return err
 }
+
+func (r *Repository) ListIdentities(userID string) ([]Identity, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar identidades: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []Identity
+	for rows.Next() {
+		var identity Identity
+		var email sql.NullString
+
+		if err := rows.Scan(
+			&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &email, &identity.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao ler identidade: %w", err)
+		}
+
+		identity.Email = email.String
+		identities = append(identities, identity)
+	}
+
+	return identities, nil
+}
+
+// DeleteIdentity desvincula o provedor. Retorna false se não havia vínculo.
+func (r *Repository) DeleteIdentity(userID, provider string) (bool, error) {
+	result, err := r.db.Exec(
+		`DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`,
+		userID, provider,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao desvincular identidade: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
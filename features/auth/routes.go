@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"loginbackend/internal/http/middleware"
 	"loginbackend/internal/http/ratelimit"
+	"loginbackend/pkg/jwtkeys"
+	"loginbackend/pkg/rbac"
 	"net/http"
 	"time"
 
@@ -11,7 +14,7 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-func Routes(handler *Handler, redisClient *redis.Client) (string, func(r chi.Router)) {
+func Routes(handler *Handler, keys *jwtkeys.Manager, redisClient *redis.Client, permissions middleware.PermissionResolver) (string, func(r chi.Router)) {
 	return "/auth", func(r chi.Router) {
 
 		// Rate Limit específico para LOGIN (Anti-Brute Force)
@@ -26,8 +29,111 @@ func Routes(handler *Handler, redisClient *redis.Client) (string, func(r chi.Rou
 			}),
 		)
 
+		// Rate Limit para recuperação de conta (envia email — mais restrito)
+		passwordLimiter := httprate.Limit(
+			5,
+			1*time.Minute,
+			httprate.WithKeyFuncs(httprate.KeyByIP),
+			httprate.WithLimitCounter(ratelimit.NewRedisLimitCounter(redisClient, "password-rate-limit:")),
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Muitas solicitações. Aguarde 1 minuto.", 429)
+			}),
+		)
+
+		// Rate Limit próprio para reenvio de verificação (envia email)
+		verificationLimiter := httprate.Limit(
+			3,
+			10*time.Minute,
+			httprate.WithKeyFuncs(httprate.KeyByIP),
+			httprate.WithLimitCounter(ratelimit.NewRedisLimitCounter(redisClient, "verify-email-rate-limit:")),
+			httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Muitos reenvios. Aguarde alguns minutos.", 429)
+			}),
+		)
+
 		r.With(loginLimiter).Post("/login", handler.Login)
+		r.With(loginLimiter).Post("/login/mfa", handler.VerifyMFA)
+		r.With(loginLimiter).Post("/login/mfa/webauthn/begin", handler.BeginWebAuthnMFA)
+		r.With(loginLimiter).Post("/login/mfa/webauthn", handler.VerifyWebAuthnMFA)
 		r.Post("/refresh", handler.Refresh)
 		r.Post("/logout", handler.Logout)
+
+		// Recuperação de conta
+		r.With(passwordLimiter).Post("/password/forgot", handler.ForgotPassword)
+		r.With(passwordLimiter).Post("/password/reset", handler.ResetPassword)
+
+		// Login social (OIDC)
+		r.Get("/oidc/providers", handler.ListOIDCProviders)
+		r.With(loginLimiter).Post("/oidc/{provider}/start", handler.StartOIDCLogin)
+		r.With(loginLimiter).Post("/oidc/callback", handler.OIDCCallback)
+
+		// Login sem senha (link por email)
+		r.With(loginLimiter).Post("/magic-link", handler.SendMagicLink)
+		r.With(loginLimiter).Post("/magic-link/consume", handler.ConsumeMagicLink)
+
+		// Login sem senha (passkey)
+		r.With(loginLimiter).Post("/webauthn/login/begin", handler.BeginWebAuthnLogin)
+		r.With(loginLimiter).Post("/webauthn/login/finish", handler.FinishWebAuthnLogin)
+
+		// Servidor OAuth2 (endpoint de token é público: autentica o cliente)
+		r.With(loginLimiter).Post("/oauth/token", handler.Token)
+
+		// Verificação de email
+		r.Get("/verify-email", handler.VerifyEmail)
+		r.With(verificationLimiter).Post("/verify-email/resend", handler.ResendVerification)
+
+		// Userinfo: só aceita access tokens de clientes OAuth, que por sua
+		// vez não valem em nenhuma outra rota (ver AuthMiddleware).
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.OAuthClientMiddleware(keys, redisClient))
+			r.Get("/oauth/userinfo", handler.UserInfo)
+			r.Post("/oauth/userinfo", handler.UserInfo)
+		})
+
+		// Rotas Protegidas (Requerem Login)
+		r.Group(func(r chi.Router) {
+			// Personal access tokens não valem aqui: sessões, MFA e
+			// consentimentos OAuth exigem um login de verdade. Pelo mesmo
+			// motivo nada aqui aceita um token de personificação.
+			r.Use(middleware.AuthMiddleware(keys, redisClient, nil))
+			r.Use(middleware.DenyImpersonation)
+
+			// Sessões por dispositivo
+			r.Post("/logout-all", handler.LogoutAll)
+			r.Get("/sessions", handler.ListSessions)
+			r.Delete("/sessions/{id}", handler.RevokeSession)
+
+			// TOTP (2FA)
+			r.Get("/mfa/totp", handler.TotpStatus)
+			r.Post("/mfa/totp/setup", handler.SetupTotp)
+			r.Post("/mfa/totp/confirm", handler.ConfirmTotp)
+			r.Post("/mfa/totp/disable", handler.DisableTotp)
+			r.Post("/mfa/recovery-codes", handler.RegenerateRecoveryCodes)
+
+			// Passkeys (WebAuthn)
+			r.Post("/webauthn/register/begin", handler.BeginWebAuthnRegistration)
+			r.Post("/webauthn/register/finish", handler.FinishWebAuthnRegistration)
+			r.Get("/webauthn/credentials", handler.ListWebAuthnCredentials)
+			r.Delete("/webauthn/credentials/{id}", handler.DeleteWebAuthnCredential)
+
+			// Provedores vinculados (login social)
+			r.Get("/identities", handler.ListIdentities)
+			r.Post("/identities/callback", handler.LinkIdentityCallback)
+			r.Post("/identities/{provider}", handler.StartLinkIdentity)
+			r.Delete("/identities/{provider}", handler.UnlinkIdentity)
+
+			// Servidor OAuth2: consentimento
+			r.Get("/oauth/authorize", handler.AuthorizeInfo)
+			r.Post("/oauth/authorize", handler.Authorize)
+			r.Get("/oauth/consents", handler.ListConsents)
+			r.Delete("/oauth/consents/{clientID}", handler.RevokeConsent)
+
+			// Administração (permissões de sistema do papel)
+			r.With(middleware.RequireSystemPermission(permissions, rbac.UsersUnlock)).Post("/users/{id}/unlock", handler.UnlockAccount)
+			r.With(middleware.RequireSystemPermission(permissions, rbac.UsersImpersonate)).Post("/impersonate/{userID}", handler.Impersonate)
+			r.With(middleware.RequireSystemPermission(permissions, rbac.OAuthClientsManage)).Post("/oauth/clients", handler.CreateOAuthClient)
+			r.With(middleware.RequireSystemPermission(permissions, rbac.OAuthClientsManage)).Get("/oauth/clients", handler.ListOAuthClients)
+			r.With(middleware.RequireSystemPermission(permissions, rbac.OAuthClientsManage)).Delete("/oauth/clients/{clientID}", handler.DeleteOAuthClient)
+		})
 	}
 }
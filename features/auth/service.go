@@ -2,42 +2,210 @@ package auth
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"loginbackend/features/shared/models"
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/jwtkeys"
+	"loginbackend/pkg/mailer"
+	"loginbackend/pkg/oidc"
+	"loginbackend/pkg/password"
 	"loginbackend/pkg/utils"
+	"loginbackend/pkg/webauthn"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// reuseGracePeriod tolera refreshes concorrentes do mesmo dispositivo
+// antes de tratar um token já rotacionado como roubado.
+const reuseGracePeriod = 10 * time.Second
+
+// passwordResetThrottle é o intervalo mínimo entre dois emails de reset
+// para a mesma conta.
+const passwordResetThrottle = 1 * time.Minute
+
+// magicLinkThrottle é o intervalo mínimo entre dois links de login para
+// a mesma conta.
+const magicLinkThrottle = 1 * time.Minute
+
+// emailVerificationThrottle é o intervalo mínimo entre dois reenvios do
+// link de verificação para a mesma conta.
+const emailVerificationThrottle = 1 * time.Minute
+
+// accountSetupExpiry é a validade do link para definir a senha de uma
+// conta criada por um administrador (ex: importação em lote).
+const accountSetupExpiry = 7 * 24 * time.Hour
+
 type Service struct {
 	repo          *Repository
 	redis         *redis.Client
-	jwtSecret     string
+	keys          *jwtkeys.Manager
 	accessExpiry  time.Duration
 	refreshExpiry time.Duration
+	mfaExpiry     time.Duration
+	totpIssuer    string
+
+	mailer              mailer.Sender
+	appURL              string
+	passwordResetExpiry time.Duration
+	passwordPolicy      *password.Policy
+
+	emailVerificationExpiry time.Duration
+	requireVerifiedEmail    bool
+	magicLinkExpiry         time.Duration
+
+	audit            *audit.Logger
+	maxLoginAttempts int
+	lockoutDuration  time.Duration
+
+	impersonationExpiry time.Duration
+	permissions         RolePermissionResolver
+
+	oidcProviders   map[string]*oidc.Provider
+	oidcRedirectURL string
+	oidcStateExpiry time.Duration
+	users           UserProvisioner
+
+	publicURL         string
+	oauthAuthorizeURL string
+	oauthCodeExpiry   time.Duration
+
+	webauthn *webauthn.RelyingParty
 }
 
 type Config struct {
-	JWTSecret     string
+	// Keys assina os access tokens (RS256/EdDSA, ou HS256 legado).
+	Keys          *jwtkeys.Manager
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
+
+	// MFAExpiry é a validade do desafio emitido entre a senha e o código TOTP.
+	MFAExpiry time.Duration
+	// TOTPIssuer aparece como nome da conta no app autenticador.
+	TOTPIssuer string
+
+	// Mailer envia os links de recuperação de conta; AppURL é a base
+	// do frontend usada para montar esses links.
+	Mailer              mailer.Sender
+	AppURL              string
+	PasswordResetExpiry time.Duration
+	// PasswordPolicy vale para a redefinição de senha e marca senhas
+	// expiradas no login.
+	PasswordPolicy *password.Policy
+
+	EmailVerificationExpiry time.Duration
+	// RequireVerifiedEmail faz o Login recusar contas com email não verificado.
+	RequireVerifiedEmail bool
+	// MagicLinkExpiry é a validade do link de login sem senha.
+	MagicLinkExpiry time.Duration
+
+	// Audit registra bloqueios/desbloqueios de conta e personificações.
+	Audit *audit.Logger
+	// MaxLoginAttempts falhas seguidas bloqueiam a conta por LockoutDuration.
+	MaxLoginAttempts int
+	LockoutDuration  time.Duration
+
+	// ImpersonationExpiry é a validade do token de personificação.
+	ImpersonationExpiry time.Duration
+	// RolePermissions impede personificar contas com papel administrativo.
+	RolePermissions RolePermissionResolver
+
+	// OIDCProviders habilita o login social; OIDCRedirectURL é a página
+	// do frontend que recebe o code/state e chama /auth/oidc/callback.
+	OIDCProviders   []*oidc.Provider
+	OIDCRedirectURL string
+	OIDCStateExpiry time.Duration
+
+	// PublicURL é a base pública desta API (endpoints do discovery);
+	// OAuthAuthorizeURL é a tela de consentimento do frontend.
+	PublicURL         string
+	OAuthAuthorizeURL string
+	OAuthCodeExpiry   time.Duration
+
+	// WebAuthn habilita as passkeys (nil = desabilitadas).
+	WebAuthn *webauthn.RelyingParty
 }
 
 func NewService(repo *Repository, redisClient *redis.Client, cfg Config) *Service {
+	if cfg.MFAExpiry == 0 {
+		cfg.MFAExpiry = 5 * time.Minute
+	}
+	if cfg.TOTPIssuer == "" {
+		cfg.TOTPIssuer = "LoginBackend"
+	}
+	if cfg.PasswordResetExpiry == 0 {
+		cfg.PasswordResetExpiry = 30 * time.Minute
+	}
+	if cfg.PasswordPolicy == nil {
+		cfg.PasswordPolicy = &password.Policy{MinLength: 8}
+	}
+	if cfg.EmailVerificationExpiry == 0 {
+		cfg.EmailVerificationExpiry = 24 * time.Hour
+	}
+	if cfg.MagicLinkExpiry == 0 {
+		cfg.MagicLinkExpiry = 15 * time.Minute
+	}
+	if cfg.MaxLoginAttempts == 0 {
+		cfg.MaxLoginAttempts = 10
+	}
+	if cfg.LockoutDuration == 0 {
+		cfg.LockoutDuration = 15 * time.Minute
+	}
+	if cfg.ImpersonationExpiry == 0 {
+		cfg.ImpersonationExpiry = 15 * time.Minute
+	}
+	if cfg.OIDCStateExpiry == 0 {
+		cfg.OIDCStateExpiry = 10 * time.Minute
+	}
+	if cfg.OAuthCodeExpiry == 0 {
+		cfg.OAuthCodeExpiry = 1 * time.Minute
+	}
+
+	oidcProviders := make(map[string]*oidc.Provider, len(cfg.OIDCProviders))
+	for _, provider := range cfg.OIDCProviders {
+		oidcProviders[provider.Name()] = provider
+	}
+
 	return &Service{
 		repo:          repo,
 		redis:         redisClient,
-		jwtSecret:     cfg.JWTSecret,
+		keys:          cfg.Keys,
 		accessExpiry:  cfg.AccessExpiry,
 		refreshExpiry: cfg.RefreshExpiry,
+		mfaExpiry:     cfg.MFAExpiry,
+		totpIssuer:    cfg.TOTPIssuer,
+
+		mailer:              cfg.Mailer,
+		appURL:              cfg.AppURL,
+		passwordResetExpiry: cfg.PasswordResetExpiry,
+		passwordPolicy:      cfg.PasswordPolicy,
+
+		emailVerificationExpiry: cfg.EmailVerificationExpiry,
+		requireVerifiedEmail:    cfg.RequireVerifiedEmail,
+		magicLinkExpiry:         cfg.MagicLinkExpiry,
+
+		audit:            cfg.Audit,
+		maxLoginAttempts: cfg.MaxLoginAttempts,
+		lockoutDuration:  cfg.LockoutDuration,
+
+		impersonationExpiry: cfg.ImpersonationExpiry,
+		permissions:         cfg.RolePermissions,
+
+		oidcProviders:   oidcProviders,
+		oidcRedirectURL: cfg.OIDCRedirectURL,
+		oidcStateExpiry: cfg.OIDCStateExpiry,
+
+		publicURL:         cfg.PublicURL,
+		oauthAuthorizeURL: cfg.OAuthAuthorizeURL,
+		oauthCodeExpiry:   cfg.OAuthCodeExpiry,
+
+		webauthn: cfg.WebAuthn,
 	}
 }
 
-func (s *Service) Login(email, password string) (*LoginResponse, error) {
+func (s *Service) Login(email, password string, client ClientInfo) (*LoginResponse, error) {
 	user, err := s.repo.FindUserByEmail(email)
 	if err != nil {
 		return nil, errors.New("erro ao buscar usuário")
@@ -51,24 +219,81 @@ func (s *Service) Login(email, password string) (*LoginResponse, error) {
 		return nil, errors.New("usuário inativo")
 	}
 
+	if err := s.checkLockout(user); err != nil {
+		return nil, err
+	}
+
 	// Verificar senha
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+	if !utils.CheckPassword(password, user.PasswordHash) {
+		s.registerFailedLogin(user, client)
 		return nil, errors.New("senha incorreta")
 	}
 
-	// Atualizar último login
-	if err := s.repo.UpdateLastLogin(user.ID); err != nil {
-		return nil, fmt.Errorf("erro ao atualizar último login: %w", err)
+	s.upgradePasswordHash(user, password)
+
+	// Checado só depois da senha para não revelar o estado da conta a
+	// quem não sabe a senha.
+	if s.requireVerifiedEmail && !user.IsEmailVerified {
+		return nil, errors.New("email não verificado. Verifique sua caixa de entrada")
 	}
 
-	// Gerar tokens
-	accessToken, err := utils.GenerateJWT(utils.TokenClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		RoleID: user.RoleID,
-	}, s.jwtSecret, s.accessExpiry)
+	// Segundo fator: com TOTP ou passkey cadastrados, a senha correta só
+	// rende um desafio de curta duração — os tokens reais saem em
+	// VerifyMFA / VerifyWebAuthnMFA.
+	methods, err := s.mfaMethods(user)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+		return nil, errors.New("erro ao buscar usuário")
+	}
+
+	var response *LoginResponse
+	if len(methods) > 0 {
+		response, err = s.createMFAChallenge(user.ID, methods)
+	} else {
+		response, err = s.issueTokens(user, client)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Senha vencida não bloqueia o login: o frontend usa o aviso para
+	// levar o usuário à troca de senha.
+	response.PasswordExpired = s.passwordPolicy.Expired(user.LastPasswordUpdate)
+	return response, nil
+}
+
+// upgradePasswordHash refaz o hash com o algoritmo/parâmetros atuais
+// quando o salvo está desatualizado (ex: bcrypt antigo → argon2id). Só é
+// possível no login, com a senha em texto em mãos. Falha aqui não impede
+// o login: fica para a próxima vez.
+func (s *Service) upgradePasswordHash(user *models.User, password string) {
+	if !utils.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		log.Printf("⚠️ Erro ao refazer hash de senha: user=%s: %v", user.ID, err)
+		return
+	}
+
+	if err := s.repo.UpgradePasswordHash(user.ID, user.PasswordHash, hash); err != nil {
+		log.Printf("⚠️ Erro ao refazer hash de senha: user=%s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// issueTokens conclui o login: atualiza last_login_at, gera o par de
+// tokens e abre uma nova sessão para o dispositivo. Ponto único usado
+// por todos os fluxos que terminam em sessão autenticada (senha, MFA, ...).
+// Só aqui as falhas de login são zeradas: a senha certa sem o segundo
+// fator não conta como sucesso.
+func (s *Service) issueTokens(user *models.User, client ClientInfo) (*LoginResponse, error) {
+	s.clearFailedLogins(user.ID)
+
+	// Atualizar último login
+	if err := s.repo.UpdateLastLogin(user.ID); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar último login: %w", err)
 	}
 
 	refreshToken, err := utils.GenerateRefreshToken()
@@ -76,10 +301,36 @@ func (s *Service) Login(email, password string) (*LoginResponse, error) {
 		return nil, fmt.Errorf("erro ao gerar refresh token: %w", err)
 	}
 
-	if err := s.repo.SaveRefreshToken(user.ID, refreshToken); err != nil {
+	// Cada login é um dispositivo novo — não derruba as sessões existentes.
+	session := Session{
+		ID:        utils.GenerateSnowflakeID(),
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(s.refreshExpiry),
+	}
+
+	if err := s.repo.CreateSession(session); err != nil {
 		return nil, fmt.Errorf("erro ao salvar refresh token: %w", err)
 	}
 
+	return s.buildLoginResponse(user, refreshToken, session.ID)
+}
+
+// buildLoginResponse gera o access token e monta a resposta padrão.
+func (s *Service) buildLoginResponse(user *models.User, refreshToken, sessionID string) (*LoginResponse, error) {
+	accessToken, err := utils.GenerateJWT(utils.TokenClaims{
+		UserID:       user.ID,
+		Email:        user.Email,
+		RoleID:       user.RoleID,
+		SessionID:    sessionID,
+		TokenVersion: user.TokenVersion,
+	}, s.keys, s.accessExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+	}
+
 	userResponse := &UserResponse{
 		ID:        user.ID,
 		Email:     user.Email,
@@ -97,19 +348,22 @@ func (s *Service) Login(email, password string) (*LoginResponse, error) {
 	}, nil
 }
 
-func (s *Service) RefreshToken(refreshToken string) (*LoginResponse, error) {
-	user, err := s.repo.FindUserByRefreshToken(refreshToken)
-	if err != nil || user == nil {
-		return nil, errors.New("refresh token inválido")
-	}
+// RefreshToken rotaciona o refresh token da sessão do dispositivo que o
+// apresentou. As demais sessões do usuário não são afetadas.
+func (s *Service) RefreshToken(refreshToken string, client ClientInfo) (*LoginResponse, error) {
+	tokenHash := utils.HashToken(refreshToken)
 
-	accessToken, err := utils.GenerateJWT(utils.TokenClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		RoleID: user.RoleID,
-	}, s.jwtSecret, s.accessExpiry)
+	session, user, err := s.repo.FindActiveSessionByTokenHash(tokenHash)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+		return nil, errors.New("refresh token inválido")
+	}
+	if session == nil {
+		s.detectReuse(tokenHash)
+		return nil, errors.New("refresh token inválido")
+	}
+	// Refresh tokens de clientes OAuth só valem em /auth/oauth/token.
+	if session.ClientID != "" {
+		return nil, errors.New("refresh token inválido")
 	}
 
 	newRefreshToken, err := utils.GenerateRefreshToken()
@@ -117,74 +371,216 @@ func (s *Service) RefreshToken(refreshToken string) (*LoginResponse, error) {
 		return nil, fmt.Errorf("erro ao gerar refresh token: %w", err)
 	}
 
-	if err := s.repo.SaveRefreshToken(user.ID, newRefreshToken); err != nil {
+	rotated, err := s.repo.RotateSession(
+		session.ID, tokenHash, utils.HashToken(newRefreshToken),
+		client.UserAgent, client.IPAddress, time.Now().Add(s.refreshExpiry),
+	)
+	if err != nil {
 		return nil, fmt.Errorf("erro ao salvar refresh token: %w", err)
 	}
+	if !rotated {
+		return nil, errors.New("refresh token inválido")
+	}
 
-	userResponse := &UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		RoleID:    user.RoleID,
-		CreatedAt: user.CreatedAt,
+	return s.buildLoginResponse(user, newRefreshToken, session.ID)
+}
+
+// detectReuse trata um refresh token que não pertence a nenhuma sessão
+// ativa. Se ele já foi rotacionado antes, alguém tem uma cópia do
+// cookie: a sessão inteira (família) é revogada, junto com os access
+// tokens já emitidos para ela — tanto o atacante quanto a vítima
+// precisam logar de novo.
+func (s *Service) detectReuse(tokenHash string) {
+	rotated, err := s.repo.FindRotatedToken(tokenHash)
+	if err != nil || rotated == nil {
+		return
 	}
 
-	return &LoginResponse{
-		User:         userResponse,
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(s.accessExpiry.Seconds()),
-	}, nil
+	// Duas abas renovando ao mesmo tempo geram um "reuso" legítimo de
+	// poucos milissegundos; só recusamos, sem derrubar a sessão.
+	if time.Since(rotated.RotatedAt) < reuseGracePeriod {
+		return
+	}
+
+	log.Printf("🚨 Reuso de refresh token detectado: user=%s, session=%s. Revogando sessão.",
+		rotated.UserID, rotated.SessionID)
+
+	if _, err := s.repo.RevokeUserSession(rotated.UserID, rotated.SessionID, RevokedReuseDetected); err != nil {
+		log.Printf("❌ Erro ao revogar sessão comprometida: %v", err)
+	}
+
+	if err := s.blacklistSession(rotated.SessionID); err != nil {
+		log.Printf("❌ Erro ao revogar access tokens da sessão: %v", err)
+	}
 }
 
-func (s *Service) Logout(tokenString, userID string) error {
-	// 1. Limpar Refresh Token no Banco (Postgres)
-	if err := s.repo.ClearRefreshToken(userID); err != nil {
-		return err
+// blacklistSession invalida todos os access tokens (claim "sid") emitidos
+// para a sessão. O TTL cobre a vida máxima de um access token.
+func (s *Service) blacklistSession(sessionID string) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("blacklist:session:%s", sessionID)
+
+	if err := s.redis.Set(ctx, key, "revoked", s.maxTokenLifetime()).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar sessão na blacklist: %w", err)
+	}
+	return nil
+}
+
+// Logout encerra apenas a sessão do dispositivo atual (identificada
+// pelo refresh token do cookie) e revoga o access token apresentado.
+func (s *Service) Logout(accessToken, refreshToken string) error {
+	// 1. Revogar a sessão no Banco (Postgres)
+	if refreshToken != "" {
+		sessionID, err := s.repo.RevokeSessionByTokenHash(utils.HashToken(refreshToken), RevokedLogout)
+		if err != nil {
+			return err
+		}
+		if sessionID != "" {
+			if err := s.blacklistSession(sessionID); err != nil {
+				return err
+			}
+		}
 	}
 
 	// 2. Blacklist do Access Token no Redis
-	return s.addToBlacklist(tokenString)
+	if accessToken == "" {
+		return nil
+	}
+	return s.addToBlacklist(accessToken)
 }
 
-// Helper para adicionar à Blacklist
-func (s *Service) addToBlacklist(tokenString string) error {
-	// Parse do token (sem validar assinatura, apenas para pegar o 'exp')
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+// LogoutAll encerra todos os dispositivos do usuário ("sair de todos").
+func (s *Service) LogoutAll(userID string) error {
+	return s.RevokeAllSessions(userID, RevokedLogoutAll)
+}
+
+// RevokeAllSessions encerra todos os dispositivos do usuário e invalida
+// todos os access tokens já emitidos para ele. Usado também por 'users'
+// (troca de senha, desativação).
+func (s *Service) RevokeAllSessions(userID, reason string) error {
+	sessionIDs, err := s.repo.RevokeAllUserSessions(userID, reason)
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		if err := s.blacklistSession(sessionID); err != nil {
+			return err
+		}
+	}
+
+	// Trocar a senha é como o usuário recupera uma conta comprometida:
+	// personal access tokens (que não dependem de sessão) caem junto.
+	if reason == RevokedPasswordChanged || reason == RevokedPasswordReset {
+		if err := s.repo.DeleteUserPersonalTokens(userID); err != nil {
+			return err
+		}
+	}
+
+	return s.InvalidateAccessTokens(userID)
+}
+
+// InvalidateAccessTokens avança a época do usuário: os refresh tokens
+// continuam válidos, mas todo access token emitido antes precisa ser
+// renovado (ex: mudança de role, para o novo role_id valer já).
+//
+// A versão vai para o Redis com TTL = maior validade de access token
+// (inclusive os de personificação). Depois disso nenhum token da época
+// anterior pode estar vivo, então a ausência da chave no AuthMiddleware
+// significa "nada a recusar".
+func (s *Service) InvalidateAccessTokens(userID string) error {
+	version, err := s.repo.IncrementTokenVersion(userID)
 	if err != nil {
-		return fmt.Errorf("erro ao ler token para blacklist: %w", err)
+		return err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return errors.New("claims inválidos")
+	ctx := context.Background()
+	key := fmt.Sprintf("token_version:%s", userID)
+	if err := s.redis.Set(ctx, key, version, s.maxTokenLifetime()).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar versão dos tokens: %w", err)
 	}
 
-	// Calcular tempo restante de vida do token
-	var exp int64
-	switch v := claims["exp"].(type) {
-	case float64:
-		exp = int64(v)
-	case json.Number:
-		exp, _ = v.Int64()
+	return nil
+}
+
+// maxTokenLifetime é a vida do access token mais longo que o serviço
+// emite: marcas de revogação no Redis precisam durar pelo menos isso.
+func (s *Service) maxTokenLifetime() time.Duration {
+	return max(s.accessExpiry, s.impersonationExpiry)
+}
+
+// ListSessions lista os dispositivos logados. currentRefreshToken (o
+// cookie de quem chamou, se houver) é usado só para marcar Current.
+func (s *Service) ListSessions(userID, currentRefreshToken string) ([]SessionResponse, error) {
+	sessions, err := s.repo.ListActiveSessions(userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if exp == 0 {
-		return nil // Sem expiração, não faz nada (ou força um default)
+	currentHash := ""
+	if currentRefreshToken != "" {
+		currentHash = utils.HashToken(currentRefreshToken)
 	}
 
-	expiresAt := time.Unix(exp, 0)
-	timeRemaining := time.Until(expiresAt)
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.TokenHash == currentHash,
+			ClientID:   session.ClientID,
+		})
+	}
 
-	// Se já expirou, não precisa salvar no Redis
+	return response, nil
+}
+
+// SessionStartedAt é o momento do login que abriu a sessão (a rotação do
+// refresh token não muda a data), ou nil se ela não está mais ativa.
+// Permite a users exigir um login recente em ações irreversíveis.
+func (s *Service) SessionStartedAt(userID, sessionID string) (*time.Time, error) {
+	if sessionID == "" {
+		return nil, nil
+	}
+	return s.repo.SessionCreatedAt(userID, sessionID)
+}
+
+// RevokeSession encerra um dispositivo específico do próprio usuário.
+func (s *Service) RevokeSession(userID, sessionID string) error {
+	revoked, err := s.repo.RevokeUserSession(userID, sessionID, RevokedByUser)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("sessão não encontrada")
+	}
+
+	// O dispositivo revogado não pode continuar usando o access token atual.
+	return s.blacklistSession(sessionID)
+}
+
+// addToBlacklist revoga um access token pelo jti até ele expirar.
+// A assinatura é validada: um token inválido ou já expirado não precisa
+// (nem deve) ocupar espaço na blacklist.
+func (s *Service) addToBlacklist(tokenString string) error {
+	claims, err := utils.ValidateJWT(tokenString, s.keys)
+	if err != nil {
+		return nil
+	}
+
+	// Calcular tempo restante de vida do token
+	timeRemaining := time.Until(claims.ExpiresAt.Time)
 	if timeRemaining <= 0 {
 		return nil
 	}
 
-	// Salvar no Redis: Chave = "blacklist:{token}", Valor = "revoked", TTL = timeRemaining
+	// Salvar no Redis: Chave = "blacklist:jti:{jti}", Valor = "revoked", TTL = timeRemaining
 	ctx := context.Background()
-	key := fmt.Sprintf("blacklist:%s", tokenString)
+	key := fmt.Sprintf("blacklist:jti:%s", claims.ID)
 
 	if err := s.redis.Set(ctx, key, "revoked", timeRemaining).Err(); err != nil {
 		return fmt.Errorf("erro ao salvar na blacklist: %w", err)
@@ -195,5 +591,10 @@ func (s *Service) addToBlacklist(tokenString string) error {
 
 // ValidateToken valida um token JWT e retorna os claims
 func (s *Service) ValidateToken(tokenString string) (*utils.TokenClaims, error) {
-	return utils.ValidateJWT(tokenString, s.jwtSecret)
+	return utils.ValidateJWT(tokenString, s.keys)
+}
+
+// JWKS expõe as chaves públicas de verificação dos access tokens.
+func (s *Service) JWKS() jwtkeys.JWKSet {
+	return s.keys.JWKS()
 }
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	httpresponse "loginbackend/internal/http/response"
+)
+
+// VerifyEmail
+// @Summary Verificar email
+// @Description Consome o link enviado por email e marca a conta como verificada
+// @Tags auth
+// @Produce json
+// @Param token query string true "Token recebido por email"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Router /auth/verify-email [get]
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "token obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.VerifyEmail(token); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "email verificado com sucesso"})
+}
+
+// ResendVerification
+// @Summary Reenviar link de verificação
+// @Description Reenvia o link para contas não verificadas. A resposta é sempre a mesma, exista o email ou não.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResendVerificationRequest true "Email da conta"
+// @Success 200 {object} Response
+// @Router /auth/verify-email/resend [post]
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req ResendVerificationRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	if err := h.service.ResendVerification(req.Email); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao processar solicitação"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{
+		Message: "se a conta existir e ainda não estiver verificada, um novo link foi enviado",
+	})
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"loginbackend/pkg/mailer"
+	"loginbackend/pkg/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SendVerification gera um link de verificação para o email informado e
+// o envia. Usado pela feature users no cadastro e na troca de email.
+func (s *Service) SendVerification(userID, email, name string) error {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return fmt.Errorf("erro ao gerar token de verificação: %w", err)
+	}
+
+	payload, err := json.Marshal(emailVerification{UserID: userID, Email: email})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("email_verify:%s", utils.HashToken(token))
+
+	if err := s.redis.Set(ctx, key, payload, s.emailVerificationExpiry).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar token de verificação: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(s.appURL, "/"), token)
+
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Confirme seu email",
+		Body: fmt.Sprintf(
+			"Olá, %s.\n\nConfirme o seu endereço de email pelo link abaixo (válido por %d horas):\n\n%s\n\nSe você não criou esta conta, ignore este email.",
+			name, int(s.emailVerificationExpiry.Hours()), link,
+		),
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		return fmt.Errorf("erro ao enviar email de verificação: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification reenvia o link para contas ainda não verificadas.
+// Assim como ForgotPassword, não revela se o email existe.
+func (s *Service) ResendVerification(email string) error {
+	user, err := s.repo.FindUserByEmail(email)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil || !user.IsActive || user.IsEmailVerified {
+		return nil
+	}
+
+	ctx := context.Background()
+	throttleKey := fmt.Sprintf("email_verify_throttle:%s", user.ID)
+	fresh, err := s.redis.SetNX(ctx, throttleKey, "1", emailVerificationThrottle).Result()
+	if err != nil {
+		return fmt.Errorf("erro ao registrar reenvio: %w", err)
+	}
+	if !fresh {
+		return nil
+	}
+
+	if err := s.SendVerification(user.ID, user.Email, user.Name); err != nil {
+		log.Printf("❌ Erro ao reenviar verificação para %s: %v", user.Email, err)
+		return err
+	}
+
+	return nil
+}
+
+// VerifyEmail consome o link (uso único) e marca a conta como verificada.
+func (s *Service) VerifyEmail(token string) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("email_verify:%s", utils.HashToken(token))
+
+	payload, err := s.redis.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return errors.New("link de verificação inválido ou expirado")
+		}
+		return fmt.Errorf("erro ao ler token de verificação: %w", err)
+	}
+
+	var verification emailVerification
+	if err := json.Unmarshal(payload, &verification); err != nil {
+		return errors.New("link de verificação inválido ou expirado")
+	}
+
+	verified, err := s.repo.MarkEmailVerified(verification.UserID, verification.Email)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		// O email da conta mudou depois que o link foi enviado.
+		return errors.New("link de verificação inválido ou expirado")
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BeginWebAuthnRegistration
+// @Summary Iniciar cadastro de passkey
+// @Description Confere a senha e devolve as opções para navigator.credentials.create (buffers em base64url).
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BeginWebAuthnRegistrationRequest true "Senha atual"
+// @Success 200 {object} Response{data=webauthn.CreationOptions}
+// @Failure 400 {object} Response
+// @Router /auth/webauthn/register/begin [post]
+func (h *Handler) BeginWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req BeginWebAuthnRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	options, err := h.service.BeginWebAuthnRegistration(claims.UserID, req.Password, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: options})
+}
+
+// FinishWebAuthnRegistration
+// @Summary Concluir cadastro de passkey
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body FinishWebAuthnRegistrationRequest true "Apelido e resposta do navegador"
+// @Success 201 {object} Response{data=WebAuthnCredentialResponse}
+// @Failure 400 {object} Response
+// @Router /auth/webauthn/register/finish [post]
+func (h *Handler) FinishWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req FinishWebAuthnRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	credential, err := h.service.FinishWebAuthnRegistration(claims.UserID, req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: credential})
+}
+
+// ListWebAuthnCredentials
+// @Summary Passkeys cadastradas
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]WebAuthnCredentialResponse}
+// @Router /auth/webauthn/credentials [get]
+func (h *Handler) ListWebAuthnCredentials(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	credentials, err := h.service.ListWebAuthnCredentials(claims.UserID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar passkeys"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: credentials})
+}
+
+// DeleteWebAuthnCredential
+// @Summary Remover passkey
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da passkey"
+// @Param request body DeleteWebAuthnCredentialRequest true "Senha atual"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Router /auth/webauthn/credentials/{id} [delete]
+func (h *Handler) DeleteWebAuthnCredential(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DeleteWebAuthnCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteWebAuthnCredential(claims.UserID, chi.URLParam(r, "id"), req.Password, clientInfo(r)); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "passkey removida"})
+}
+
+// BeginWebAuthnLogin
+// @Summary Iniciar login com passkey
+// @Description Devolve as opções para navigator.credentials.get. O navegador oferece as passkeys que tiver; o email é opcional e só restringe o login àquela conta (a resposta é a mesma para qualquer email).
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body BeginWebAuthnLoginRequest false "Email (opcional)"
+// @Success 200 {object} Response{data=webauthn.RequestOptions}
+// @Router /auth/webauthn/login/begin [post]
+func (h *Handler) BeginWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	var req BeginWebAuthnLoginRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "dados inválidos", http.StatusBadRequest)
+			return
+		}
+	}
+	if h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	options, err := h.service.BeginWebAuthnLogin(req.Email)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: options})
+}
+
+// FinishWebAuthnLogin
+// @Summary Concluir login com passkey
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body FinishWebAuthnLoginRequest true "Resposta do navegador"
+// @Success 200 {object} Response{data=LoginResponse}
+// @Failure 401 {object} Response
+// @Router /auth/webauthn/login/finish [post]
+func (h *Handler) FinishWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	var req FinishWebAuthnLoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.service.FinishWebAuthnLogin(req.Credential, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	writeLoginResponse(w, response)
+}
+
+// BeginWebAuthnMFA
+// @Summary Segunda etapa do login com passkey (opções)
+// @Description Devolve as opções para navigator.credentials.get a partir do mfa_token do login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body BeginWebAuthnMFARequest true "Desafio MFA"
+// @Success 200 {object} Response{data=webauthn.RequestOptions}
+// @Failure 401 {object} Response
+// @Router /auth/login/mfa/webauthn/begin [post]
+func (h *Handler) BeginWebAuthnMFA(w http.ResponseWriter, r *http.Request) {
+	var req BeginWebAuthnMFARequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	options, err := h.service.BeginWebAuthnMFA(req.MFAToken)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: options})
+}
+
+// VerifyWebAuthnMFA
+// @Summary Segunda etapa do login (passkey)
+// @Description Troca o mfa_token + asserção da passkey pelos tokens JWT
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyWebAuthnMFARequest true "Desafio e resposta do navegador"
+// @Success 200 {object} Response{data=LoginResponse}
+// @Failure 401 {object} Response
+// @Router /auth/login/mfa/webauthn [post]
+func (h *Handler) VerifyWebAuthnMFA(w http.ResponseWriter, r *http.Request) {
+	var req VerifyWebAuthnMFARequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || h.validate.Struct(req) != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.service.VerifyWebAuthnMFA(req.MFAToken, req.Credential, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	writeLoginResponse(w, response)
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreateWebAuthnCredential(credential WebAuthnCredential) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webauthn_credentials (id, user_id, credential_id, public_key, algorithm, sign_count, transports, name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, credential.ID, credential.UserID, credential.CredentialID, credential.PublicKey,
+		credential.Algorithm, credential.SignCount, pq.Array(credential.Transports), credential.Name)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar passkey: %w", err)
+	}
+	return nil
+}
+
+// FindWebAuthnCredential busca pelo id gerado pelo autenticador.
+func (r *Repository) FindWebAuthnCredential(credentialID []byte) (*WebAuthnCredential, error) {
+	row := r.db.QueryRow(`
+		SELECT id, user_id, credential_id, public_key, algorithm, sign_count, transports, name, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE credential_id = $1
+	`, credentialID)
+
+	credential, err := scanWebAuthnCredential(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar passkey: %w", err)
+	}
+	return credential, nil
+}
+
+func (r *Repository) ListWebAuthnCredentials(userID string) ([]WebAuthnCredential, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, credential_id, public_key, algorithm, sign_count, transports, name, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar passkeys: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler passkey: %w", err)
+		}
+		credentials = append(credentials, *credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (r *Repository) HasWebAuthnCredentials(userID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM webauthn_credentials WHERE user_id = $1)
+	`, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar passkeys: %w", err)
+	}
+	return exists, nil
+}
+
+// UpdateWebAuthnSignCount grava o contador após um login. A condição no
+// WHERE impede que duas asserções concorrentes façam o contador voltar.
+func (r *Repository) UpdateWebAuthnSignCount(id string, signCount int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE webauthn_credentials SET sign_count = $2, last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (sign_count < $2 OR $2 = 0)
+	`, id, signCount)
+	if err != nil {
+		return false, fmt.Errorf("erro ao atualizar passkey: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// DeleteWebAuthnCredential remove uma passkey do próprio usuário.
+func (r *Repository) DeleteWebAuthnCredential(userID, id string) (bool, error) {
+	result, err := r.db.Exec(
+		`DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`,
+		id, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover passkey: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func scanWebAuthnCredential(row scanner) (*WebAuthnCredential, error) {
+	var credential WebAuthnCredential
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&credential.ID, &credential.UserID, &credential.CredentialID, &credential.PublicKey,
+		&credential.Algorithm, &credential.SignCount, pq.Array(&credential.Transports),
+		&credential.Name, &credential.CreatedAt, &lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = &lastUsedAt.Time
+	}
+	return &credential, nil
+}
@@ -0,0 +1,389 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/pkg/utils"
+	"loginbackend/pkg/webauthn"
+)
+
+// webauthnChallengeExpiry é a validade de um challenge entre o begin e o
+// finish (o navegador espera o usuário tocar no autenticador).
+const webauthnChallengeExpiry = 5 * time.Minute
+
+var errInvalidPasskey = errors.New("passkey inválida")
+
+func (s *Service) relyingParty() (*webauthn.RelyingParty, error) {
+	if s.webauthn == nil {
+		return nil, errors.New("passkeys não configuradas")
+	}
+	return s.webauthn, nil
+}
+
+// ============================================
+// CADASTRO
+// ============================================
+
+// BeginWebAuthnRegistration gera as opções de navigator.credentials.create.
+// O challenge fica no Redis por usuário: um novo begin substitui o anterior.
+func (s *Service) BeginWebAuthnRegistration(userID, password string, client ClientInfo) (*webauthn.CreationOptions, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	if err := s.confirmPassword(user, password, client); err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.repo.ListWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		exclude = append(exclude, webauthn.Descriptor(credential.CredentialID, credential.Transports))
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("webauthn_register:%s", userID)
+	if err := s.redis.Set(ctx, key, challenge, webauthnChallengeExpiry).Err(); err != nil {
+		return nil, fmt.Errorf("erro ao salvar challenge: %w", err)
+	}
+
+	// O user handle é o próprio ID: volta no login por passkey descoberta.
+	options := rp.CreationOptions(challenge, []byte(user.ID), user.Email, user.Name, exclude)
+	return &options, nil
+}
+
+// FinishWebAuthnRegistration valida a resposta do autenticador e cadastra
+// a passkey. Exige verificação do usuário (PIN/biometria), já que a
+// passkey também serve como fator único de login.
+func (s *Service) FinishWebAuthnRegistration(userID string, req FinishWebAuthnRegistrationRequest) (*WebAuthnCredentialResponse, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	challenge, err := s.redis.GetDel(ctx, fmt.Sprintf("webauthn_register:%s", userID)).Result()
+	if err != nil {
+		return nil, errors.New("challenge inválido ou expirado")
+	}
+
+	verified, err := rp.VerifyRegistration(req.Credential, challenge, true)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindWebAuthnCredential(verified.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("passkey já cadastrada")
+	}
+
+	credential := WebAuthnCredential{
+		ID:           utils.GenerateSnowflakeID(),
+		UserID:       userID,
+		CredentialID: verified.ID,
+		PublicKey:    verified.PublicKey,
+		Algorithm:    verified.Algorithm,
+		SignCount:    int64(verified.SignCount),
+		Transports:   verified.Transports,
+		Name:         req.Name,
+		CreatedAt:    time.Now(),
+	}
+	if credential.Transports == nil {
+		credential.Transports = []string{}
+	}
+
+	if err := s.repo.CreateWebAuthnCredential(credential); err != nil {
+		return nil, err
+	}
+
+	response := toWebAuthnCredentialResponse(credential)
+	return &response, nil
+}
+
+func (s *Service) ListWebAuthnCredentials(userID string) ([]WebAuthnCredentialResponse, error) {
+	credentials, err := s.repo.ListWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]WebAuthnCredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		response = append(response, toWebAuthnCredentialResponse(credential))
+	}
+	return response, nil
+}
+
+// DeleteWebAuthnCredential exige a senha, como DisableTotp: a passkey
+// pode ser o segundo fator da conta.
+func (s *Service) DeleteWebAuthnCredential(userID, id, password string, client ClientInfo) error {
+	user, err := s.repo.FindUserByID(userID)
+	if err != nil || user == nil {
+		return errors.New("usuário não encontrado")
+	}
+
+	if err := s.confirmPassword(user, password, client); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteWebAuthnCredential(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("passkey não encontrada")
+	}
+	return nil
+}
+
+func toWebAuthnCredentialResponse(credential WebAuthnCredential) WebAuthnCredentialResponse {
+	return WebAuthnCredentialResponse{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		Transports: credential.Transports,
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
+
+// ============================================
+// LOGIN SEM SENHA
+// ============================================
+
+// BeginWebAuthnLogin gera as opções de navigator.credentials.get. A
+// resposta nunca lista credenciais (toda passkey é discoverable — o
+// cadastro exige residentKey), então é a mesma para qualquer email e não
+// revela quais contas existem. O email, se informado, só prende o
+// challenge à conta: a asserção de outro usuário é recusada no finish.
+func (s *Service) BeginWebAuthnLogin(email string) (*webauthn.RequestOptions, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	state := webauthnChallenge{}
+
+	if email != "" {
+		user, err := s.repo.FindUserByEmail(email)
+		if err != nil {
+			return nil, errors.New("erro ao buscar usuário")
+		}
+		if user != nil {
+			state.UserID = user.ID
+		}
+	}
+
+	state.Challenge, err = webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("webauthn_login:%s", utils.HashToken(state.Challenge))
+	if err := s.redis.Set(ctx, key, payload, webauthnChallengeExpiry).Err(); err != nil {
+		return nil, fmt.Errorf("erro ao salvar challenge: %w", err)
+	}
+
+	options := rp.RequestOptions(state.Challenge, nil, "required")
+	return &options, nil
+}
+
+// FinishWebAuthnLogin conclui o login por passkey. Com verificação do
+// usuário a passkey já vale por dois fatores, então o TOTP não é pedido.
+func (s *Service) FinishWebAuthnLogin(assertion webauthn.AssertionResponse, client ClientInfo) (*LoginResponse, error) {
+	if _, err := s.relyingParty(); err != nil {
+		return nil, err
+	}
+
+	challenge, err := assertion.Challenge()
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	ctx := context.Background()
+	payload, err := s.redis.GetDel(ctx, fmt.Sprintf("webauthn_login:%s", utils.HashToken(challenge))).Result()
+	if err != nil {
+		return nil, errors.New("challenge inválido ou expirado")
+	}
+
+	var state webauthnChallenge
+	if err := json.Unmarshal([]byte(payload), &state); err != nil {
+		return nil, errors.New("challenge inválido ou expirado")
+	}
+
+	credential, err := s.findAssertedCredential(assertion)
+	if err != nil {
+		return nil, err
+	}
+	if state.UserID != "" && credential.UserID != state.UserID {
+		return nil, errInvalidPasskey
+	}
+
+	if err := s.verifyPasskey(credential, assertion, state.Challenge, true); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindUserByID(credential.UserID)
+	if err != nil || user == nil {
+		return nil, errInvalidPasskey
+	}
+	if !user.IsActive {
+		return nil, errors.New("usuário inativo")
+	}
+	if err := s.checkLockout(user); err != nil {
+		return nil, err
+	}
+	if s.requireVerifiedEmail && !user.IsEmailVerified {
+		return nil, errors.New("email não verificado. Verifique sua caixa de entrada")
+	}
+
+	return s.issueTokens(user, client)
+}
+
+// ============================================
+// SEGUNDO FATOR
+// ============================================
+
+// BeginWebAuthnMFA gera as opções da asserção para quem já passou pela
+// senha. Cada begin vale para uma tentativa de VerifyWebAuthnMFA.
+func (s *Service) BeginWebAuthnMFA(mfaToken string) (*webauthn.RequestOptions, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	tokenHash := utils.HashToken(mfaToken)
+
+	userID, err := s.redis.Get(ctx, fmt.Sprintf("mfa_pending:%s", tokenHash)).Result()
+	if err != nil {
+		return nil, errors.New("desafio MFA inválido ou expirado")
+	}
+
+	credentials, err := s.repo.ListWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) == 0 {
+		return nil, errors.New("nenhuma passkey cadastrada")
+	}
+
+	allow := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		allow = append(allow, webauthn.Descriptor(credential.CredentialID, credential.Transports))
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("mfa_webauthn:%s", tokenHash)
+	if err := s.redis.Set(ctx, key, challenge, s.mfaExpiry).Err(); err != nil {
+		return nil, fmt.Errorf("erro ao salvar challenge: %w", err)
+	}
+
+	options := rp.RequestOptions(challenge, allow, "discouraged")
+	return &options, nil
+}
+
+// VerifyWebAuthnMFA conclui o login em duas etapas com uma passkey. A
+// senha já foi conferida, então basta a presença do usuário.
+func (s *Service) VerifyWebAuthnMFA(mfaToken string, assertion webauthn.AssertionResponse, client ClientInfo) (*LoginResponse, error) {
+	if _, err := s.relyingParty(); err != nil {
+		return nil, err
+	}
+
+	return s.completeMFA(mfaToken, client, func(user *models.User) error {
+		ctx := context.Background()
+		key := fmt.Sprintf("mfa_webauthn:%s", utils.HashToken(mfaToken))
+
+		challenge, err := s.redis.GetDel(ctx, key).Result()
+		if err != nil {
+			return errors.New("challenge inválido ou expirado")
+		}
+
+		credential, err := s.findAssertedCredential(assertion)
+		if err != nil {
+			return err
+		}
+		if credential.UserID != user.ID {
+			return errInvalidPasskey
+		}
+
+		return s.verifyPasskey(credential, assertion, challenge, false)
+	})
+}
+
+// findAssertedCredential localiza a credencial usada e confere o user
+// handle, quando o autenticador o envia.
+func (s *Service) findAssertedCredential(assertion webauthn.AssertionResponse) (*WebAuthnCredential, error) {
+	credentialID, err := assertion.CredentialID()
+	if err != nil {
+		return nil, errInvalidPasskey
+	}
+
+	credential, err := s.repo.FindWebAuthnCredential(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, errInvalidPasskey
+	}
+
+	userHandle, err := assertion.UserHandle()
+	if err != nil || (userHandle != nil && string(userHandle) != credential.UserID) {
+		return nil, errInvalidPasskey
+	}
+
+	return credential, nil
+}
+
+// verifyPasskey valida a asserção e avança o contador da credencial.
+func (s *Service) verifyPasskey(credential *WebAuthnCredential, assertion webauthn.AssertionResponse, challenge string, requireUV bool) error {
+	result, err := s.webauthn.VerifyAssertion(
+		assertion, challenge, credential.PublicKey, credential.Algorithm,
+		uint32(credential.SignCount), requireUV,
+	)
+	if err != nil {
+		log.Printf("⚠️ Asserção WebAuthn recusada: user=%s, credential=%s: %v", credential.UserID, credential.ID, err)
+		return errInvalidPasskey
+	}
+
+	updated, err := s.repo.UpdateWebAuthnSignCount(credential.ID, int64(result.SignCount))
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errInvalidPasskey
+	}
+
+	return nil
+}
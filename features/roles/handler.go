@@ -0,0 +1,195 @@
+package roles
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListPermissions
+// @Summary Catálogo de permissões
+// @Description Permissões de sistema que podem ser atribuídas a papéis
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]PermissionInfo}
+// @Router /roles/permissions [get]
+func (h *Handler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: h.service.Catalog()})
+}
+
+// ListRoles
+// @Summary Listar papéis
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]Role}
+// @Router /roles [get]
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.service.List()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar papéis"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: roles})
+}
+
+// GetRole
+// @Summary Buscar papel
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID do papel"
+// @Success 200 {object} Response{data=Role}
+// @Failure 404 {object} Response
+// @Router /roles/{id} [get]
+func (h *Handler) GetRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.service.Get(id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: role})
+}
+
+// CreateRole
+// @Summary Criar papel
+// @Description Cria um papel com permissões de sistema. Só é possível conceder permissões que o seu papel já tem.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateRoleRequest true "Nome, descrição e permissões"
+// @Success 201 {object} Response{data=Role}
+// @Failure 400 {object} Response
+// @Router /roles [post]
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.service.Create(claims.UserID, claims.RoleID, req, middleware.RemoteIP(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "papel criado", Data: role})
+}
+
+// UpdateRole
+// @Summary Alterar papel
+// @Description Altera nome, descrição e/ou permissões (a lista substitui a anterior). Vale na hora para todos os usuários do papel.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID do papel"
+// @Param request body UpdateRoleRequest true "Campos a alterar"
+// @Success 200 {object} Response{data=Role}
+// @Failure 400 {object} Response
+// @Router /roles/{id} [put]
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.service.Update(claims.UserID, claims.RoleID, id, req, middleware.RemoteIP(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "papel atualizado", Data: role})
+}
+
+// DeleteRole
+// @Summary Remover papel
+// @Description Remove um papel sem usuários. SUPER_ADMIN e USER não podem ser removidos.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID do papel"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Router /roles/{id} [delete]
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(claims.UserID, claims.RoleID, id, middleware.RemoteIP(r)); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "papel removido"})
+}
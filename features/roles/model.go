@@ -0,0 +1,39 @@
+package roles
+
+import "time"
+
+// Papéis usados pelo próprio código (seed do admin e cadastro público).
+const (
+	RoleSuperAdmin = 1
+	RoleUser       = 2
+)
+
+type Role struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	IsSystem    bool      `json:"is_system"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Description string   `json:"description" validate:"max=500"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+// UpdateRoleRequest: campos nil ficam como estão; Permissions substitui
+// a lista inteira.
+type UpdateRoleRequest struct {
+	Name        *string   `json:"name,omitempty" validate:"omitempty,min=2,max=50"`
+	Description *string   `json:"description,omitempty" validate:"omitempty,max=500"`
+	Permissions *[]string `json:"permissions,omitempty" validate:"omitempty,dive,required"`
+}
+
+// PermissionInfo é um item do catálogo de permissões.
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,183 @@
+package roles
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const roleColumns = `
+	r.id, r.role_name, COALESCE(r.description, ''), COALESCE(r.is_system, false),
+	COALESCE(ARRAY(SELECT permission FROM role_permissions rp WHERE rp.role_id = r.id ORDER BY permission), '{}'),
+	r.created_at, COALESCE(r.updated_at, r.created_at)
+`
+
+func (r *Repository) List() ([]Role, error) {
+	rows, err := r.db.Query(`SELECT ` + roleColumns + ` FROM roles r ORDER BY r.id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar papéis: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler papel: %w", err)
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *Repository) FindByID(id int) (*Role, error) {
+	row := r.db.QueryRow(`SELECT `+roleColumns+` FROM roles r WHERE r.id = $1`, id)
+
+	role, err := scanRole(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar papel: %w", err)
+	}
+	return role, nil
+}
+
+func (r *Repository) NameExists(name string, exceptID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM roles WHERE LOWER(role_name) = LOWER($1) AND id <> $2)
+	`, name, exceptID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar nome do papel: %w", err)
+	}
+	return exists, nil
+}
+
+// Permissions lê só as permissões do papel (caminho quente do middleware,
+// quando não estão no cache).
+func (r *Repository) Permissions(roleID int) ([]string, error) {
+	rows, err := r.db.Query(`SELECT permission FROM role_permissions WHERE role_id = $1`, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar permissões do papel: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+// Create grava o papel e as permissões na mesma transação.
+func (r *Repository) Create(role Role) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO roles (role_name, description, is_system)
+		VALUES ($1, $2, false)
+		RETURNING id
+	`, role.Name, role.Description).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao criar papel: %w", err)
+	}
+
+	if err := replacePermissions(tx, id, role.Permissions); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// Update grava nome/descrição e substitui as permissões.
+func (r *Repository) Update(role Role) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE roles SET role_name = $2, description = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, role.ID, role.Name, role.Description); err != nil {
+		return fmt.Errorf("erro ao atualizar papel: %w", err)
+	}
+
+	if err := replacePermissions(tx, role.ID, role.Permissions); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountUsers conta as contas que usam o papel (impede a remoção).
+func (r *Repository) CountUsers(roleID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM users WHERE role_id = $1`, roleID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao contar usuários do papel: %w", err)
+	}
+	return count, nil
+}
+
+func (r *Repository) Delete(roleID int) error {
+	_, err := r.db.Exec(`DELETE FROM roles WHERE id = $1 AND NOT COALESCE(is_system, false)`, roleID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover papel: %w", err)
+	}
+	return nil
+}
+
+func replacePermissions(tx *sql.Tx, roleID int, permissions []string) error {
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("erro ao atualizar permissões: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO role_permissions (role_id, permission)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING
+	`, roleID, pq.Array(permissions)); err != nil {
+		return fmt.Errorf("erro ao gravar permissões: %w", err)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRole(row scanner) (*Role, error) {
+	var role Role
+	err := row.Scan(
+		&role.ID, &role.Name, &role.Description, &role.IsSystem,
+		pq.Array(&role.Permissions), &role.CreatedAt, &role.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
@@ -0,0 +1,30 @@
+package roles
+
+import (
+	"loginbackend/internal/http/middleware"
+	"loginbackend/pkg/jwtkeys"
+	"loginbackend/pkg/rbac"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+func Routes(handler *Handler, keys *jwtkeys.Manager, redisClient *redis.Client, tokens middleware.PersonalTokenValidator, permissions middleware.PermissionResolver) (string, func(r chi.Router)) {
+	return "/roles", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(keys, redisClient, tokens))
+		r.Use(middleware.RequireSystemPermission(permissions, rbac.RolesManage))
+
+		r.Get("/permissions", handler.ListPermissions)
+		r.Get("/", handler.ListRoles)
+		r.Get("/{id}", handler.GetRole)
+
+		// Alterar papéis é sensível: fora da personificação
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.DenyImpersonation)
+
+			r.Post("/", handler.CreateRole)
+			r.Put("/{id}", handler.UpdateRole)
+			r.Delete("/{id}", handler.DeleteRole)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package roles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/rbac"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+// permissionsCacheTTL limita quanto tempo uma instância pode ficar com
+// permissões antigas se a invalidação falhar; alterações pela API apagam
+// o cache na hora.
+const permissionsCacheTTL = 10 * time.Minute
+
+type Service struct {
+	repo     *Repository
+	redis    *redis.Client
+	audit    *audit.Logger
+	validate *validator.Validate
+}
+
+func NewService(repo *Repository, redisClient *redis.Client, auditLogger *audit.Logger) *Service {
+	return &Service{
+		repo:     repo,
+		redis:    redisClient,
+		audit:    auditLogger,
+		validate: validator.New(),
+	}
+}
+
+// RolePermissions devolve as permissões do papel, com cache no Redis
+// (é chamado a cada requisição que passa por RequireSystemPermission).
+func (s *Service) RolePermissions(roleID int) (rbac.Set, error) {
+	ctx := context.Background()
+	key := permissionsCacheKey(roleID)
+
+	if cached, err := s.redis.Get(ctx, key).Bytes(); err == nil {
+		var permissions []string
+		if err := json.Unmarshal(cached, &permissions); err == nil {
+			return rbac.NewSet(permissions...), nil
+		}
+	}
+
+	permissions, err := s.repo.Permissions(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload, err := json.Marshal(permissions); err == nil {
+		s.redis.Set(ctx, key, payload, permissionsCacheTTL)
+	}
+
+	return rbac.NewSet(permissions...), nil
+}
+
+func (s *Service) invalidate(roleID int) {
+	s.redis.Del(context.Background(), permissionsCacheKey(roleID))
+}
+
+func permissionsCacheKey(roleID int) string {
+	return "role_permissions:" + strconv.Itoa(roleID)
+}
+
+// Catalog lista as permissões que podem ser atribuídas a papéis.
+func (s *Service) Catalog() []PermissionInfo {
+	names := rbac.NewSet()
+	for name := range rbac.Catalog {
+		names[name] = struct{}{}
+	}
+
+	catalog := make([]PermissionInfo, 0, len(names))
+	for _, name := range names.List() {
+		catalog = append(catalog, PermissionInfo{Name: name, Description: rbac.Catalog[name]})
+	}
+	return catalog
+}
+
+func (s *Service) List() ([]Role, error) {
+	return s.repo.List()
+}
+
+func (s *Service) Get(id int) (*Role, error) {
+	role, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("papel não encontrado")
+	}
+	return role, nil
+}
+
+// Create cadastra um papel novo. Quem cria só pode conceder permissões
+// que o próprio papel já tem — roles:manage não vira atalho para "*".
+func (s *Service) Create(actorID string, actorRoleID int, req CreateRoleRequest, ipAddress string) (*Role, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("erro de validação: %w", err)
+	}
+
+	name := strings.ToUpper(strings.TrimSpace(req.Name))
+	permissions, err := s.checkGrantable(actorRoleID, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.NameExists(name, 0)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("já existe um papel com esse nome")
+	}
+
+	id, err := s.repo.Create(Role{Name: name, Description: req.Description, Permissions: permissions})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(actorID, audit.ActionRoleCreated, ipAddress, map[string]any{
+		"role_id": id, "name": name, "permissions": permissions,
+	})
+
+	return s.Get(id)
+}
+
+// Update altera nome, descrição e/ou permissões. O SUPER_ADMIN não é
+// editável (é o que garante que sempre exista alguém com acesso total).
+func (s *Service) Update(actorID string, actorRoleID, id int, req UpdateRoleRequest, ipAddress string) (*Role, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("erro de validação: %w", err)
+	}
+
+	role, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if role.ID == RoleSuperAdmin {
+		return nil, errors.New("o papel SUPER_ADMIN não pode ser alterado")
+	}
+	if err := s.checkManageable(actorRoleID, role); err != nil {
+		return nil, err
+	}
+
+	previous := role.Permissions
+
+	if req.Name != nil {
+		name := strings.ToUpper(strings.TrimSpace(*req.Name))
+		exists, err := s.repo.NameExists(name, role.ID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, errors.New("já existe um papel com esse nome")
+		}
+		role.Name = name
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		permissions, err := s.checkGrantable(actorRoleID, *req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+
+	if err := s.repo.Update(*role); err != nil {
+		return nil, err
+	}
+	s.invalidate(role.ID)
+
+	s.log(actorID, audit.ActionRoleUpdated, ipAddress, map[string]any{
+		"role_id":              role.ID,
+		"name":                 role.Name,
+		"previous_permissions": previous,
+		"permissions":          role.Permissions,
+	})
+
+	return s.Get(role.ID)
+}
+
+// Delete remove um papel sem usuários. Papéis de sistema não saem.
+func (s *Service) Delete(actorID string, actorRoleID, id int, ipAddress string) error {
+	role, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("papéis de sistema não podem ser removidos")
+	}
+	if err := s.checkManageable(actorRoleID, role); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountUsers(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("o papel ainda tem %d usuário(s). Mova-os para outro papel antes", count)
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate(id)
+
+	s.log(actorID, audit.ActionRoleDeleted, ipAddress, map[string]any{
+		"role_id": role.ID, "name": role.Name, "permissions": role.Permissions,
+	})
+	return nil
+}
+
+// checkGrantable valida as permissões contra o catálogo e contra o que o
+// papel de quem concede já tem. Devolve a lista sem repetições.
+func (s *Service) checkGrantable(actorRoleID int, permissions []string) ([]string, error) {
+	actor, err := s.RolePermissions(actorRoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := rbac.NewSet()
+	for _, permission := range permissions {
+		if !rbac.Valid(permission) {
+			return nil, fmt.Errorf("permissão desconhecida: %s", permission)
+		}
+		if !actor.Has(permission) {
+			return nil, fmt.Errorf("você não pode conceder a permissão %s", permission)
+		}
+		set[permission] = struct{}{}
+	}
+
+	return set.List(), nil
+}
+
+// checkManageable impede alterar ou remover um papel com permissões que
+// quem altera não tem (ex: um gerente de papéis rebaixando um admin).
+func (s *Service) checkManageable(actorRoleID int, role *Role) error {
+	actor, err := s.RolePermissions(actorRoleID)
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range role.Permissions {
+		if !actor.Has(permission) {
+			return errors.New("não é possível alterar um papel com permissões que você não tem")
+		}
+	}
+	return nil
+}
+
+// log é best-effort: a alteração já foi gravada.
+func (s *Service) log(actorID, action, ipAddress string, metadata map[string]any) {
+	if err := s.audit.Log(audit.Entry{
+		ActorID:   &actorID,
+		Action:    action,
+		IPAddress: ipAddress,
+		Metadata:  metadata,
+	}); err != nil {
+		log.Printf("⚠️ Erro ao auditar %s: %v", action, err)
+	}
+}
@@ -13,6 +13,8 @@ type User struct {
 	JobTitle  *string `json:"job_title,omitempty"`
 	Location  *string `json:"location,omitempty"`
 	AvatarURL *string `json:"avatar_url,omitempty"` // Mudou de ProfileImageUrl para AvatarURL
+	// AvatarVariants: URL de cada tamanho do avatar ("64", "256", "512")
+	AvatarVariants map[string]string `json:"avatar_variants,omitempty"`
 
 	// Campos de Endereço
 	Country    *string `json:"country,omitempty"`
@@ -28,6 +30,9 @@ type User struct {
 	LastPasswordUpdate time.Time  `json:"last_password_update"`
 	RefreshToken       *string    `json:"-"`
 	TotpSecret         *string    `json:"-"`
+	TotpEnabled        bool       `json:"totp_enabled"`
+	LockedUntil        *time.Time `json:"locked_until,omitempty"`
+	TokenVersion       int        `json:"-"`
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 	LastLoginAt        *time.Time `json:"last_login_at,omitempty"`
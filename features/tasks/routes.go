@@ -3,6 +3,7 @@ package tasks
 import (
 	"loginbackend/internal/http/middleware"
 	pkgacl "loginbackend/pkg/acl"
+	"loginbackend/pkg/jwtkeys"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/redis/go-redis/v9"
@@ -10,13 +11,15 @@ import (
 
 func Routes(
 	handler *Handler,
-	jwtSecret string,
+	keys *jwtkeys.Manager,
 	redisClient *redis.Client,
 	aclService middleware.ACLService, // INTERFACE, não tipo concreto
+	tokens middleware.PersonalTokenValidator,
+	permissions middleware.PermissionResolver,
 ) (string, func(r chi.Router)) {
 	return "/tasks", func(r chi.Router) {
 		// Middleware global de autenticação
-		r.Use(middleware.AuthMiddleware(jwtSecret, redisClient))
+		r.Use(middleware.AuthMiddleware(keys, redisClient, tokens))
 
 		// ============================================
 		// ROTAS PÚBLICAS (SEM ACL - Apenas Auth)
@@ -42,17 +45,17 @@ func Routes(
 			// GET /tasks/{id} - Ver detalhes (requer READ)
 			// NOTA: Se você tiver um handler GetTask separado, adicione aqui
 			// r.With(
-			//     middleware.RequireOwnerOrShared(aclService, acl.ResourceTask, acl.PermissionRead),
+			//     middleware.RequireOwnerOrShared(aclService, permissions, acl.ResourceTask, acl.PermissionRead),
 			// ).Get("/{id}", handler.GetTask)
 
 			// PUT /tasks/{id} - Atualizar (requer WRITE)
 			r.With(
-				middleware.RequireOwnerOrShared(aclService, pkgacl.ResourceTask, pkgacl.PermissionWrite),
+				middleware.RequireOwnerOrShared(aclService, permissions, pkgacl.ResourceTask, pkgacl.PermissionWrite),
 			).Put("/{id}", handler.UpdateTask)
 
 			// DELETE /tasks/{id} - Deletar (requer DELETE)
 			r.With(
-				middleware.RequireOwnerOrShared(aclService, pkgacl.ResourceTask, pkgacl.PermissionDelete),
+				middleware.RequireOwnerOrShared(aclService, permissions, pkgacl.ResourceTask, pkgacl.PermissionDelete),
 			).Delete("/{id}", handler.DeleteTask)
 		})
 	}
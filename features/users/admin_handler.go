@@ -0,0 +1,201 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+	"loginbackend/pkg/rbac"
+)
+
+// ListUsers
+// @Summary Listar usuários (console administrativo)
+// @Description Paginação por cursor (next_cursor), filtros, busca por nome/email e ordenação. O total com os filtros vem no header X-Total-Count.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Busca em nome e email"
+// @Param role_id query int false "Papel"
+// @Param is_active query bool false "Ativo"
+// @Param is_email_verified query bool false "Email verificado"
+// @Param created_from query string false "Criado a partir de (RFC3339 ou AAAA-MM-DD)"
+// @Param created_to query string false "Criado antes de (RFC3339 ou AAAA-MM-DD)"
+// @Param last_login_from query string false "Último login a partir de"
+// @Param last_login_to query string false "Último login antes de"
+// @Param sort query string false "created_at (padrão), name, email, last_login_at"
+// @Param order query string false "asc ou desc (padrão)"
+// @Param limit query int false "Itens por página (padrão 50, máximo 200)"
+// @Param cursor query string false "next_cursor da página anterior"
+// @Success 200 {object} Response{data=UserPage}
+// @Header 200 {integer} X-Total-Count "Total de usuários com os filtros"
+// @Failure 400 {object} Response
+// @Router /users [get]
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListUsersFilter(r.URL.Query())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	page, total, err := h.service.ListUsers(filter)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "erro ao listar usuários"
+		if errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrInvalidSort) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: message})
+		return
+	}
+
+	// Próxima página também no Link (exposto no CORS junto do X-Total-Count)
+	if page.NextCursor != "" {
+		next := r.URL.Query()
+		next.Set("cursor", page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: page})
+}
+
+func parseListUsersFilter(query url.Values) (ListUsersFilter, error) {
+	filter := ListUsersFilter{
+		Query:  query.Get("q"),
+		Sort:   query.Get("sort"),
+		Cursor: query.Get("cursor"),
+		Desc:   true,
+	}
+
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Desc = false
+	default:
+		return filter, errors.New("order deve ser asc ou desc")
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, errors.New("limit inválido")
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("role_id"); v != "" {
+		roleID, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("role_id inválido")
+		}
+		filter.RoleID = &roleID
+	}
+
+	bools := map[string]**bool{
+		"is_active":         &filter.IsActive,
+		"is_email_verified": &filter.IsEmailVerified,
+	}
+	for name, target := range bools {
+		if v := query.Get(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return filter, errors.New(name + " inválido")
+			}
+			*target = &b
+		}
+	}
+
+	dates := map[string]**time.Time{
+		"created_from":    &filter.CreatedFrom,
+		"created_to":      &filter.CreatedTo,
+		"last_login_from": &filter.LastLoginFrom,
+		"last_login_to":   &filter.LastLoginTo,
+	}
+	for name, target := range dates {
+		if v := query.Get(name); v != "" {
+			t, err := parseFilterTime(v)
+			if err != nil {
+				return filter, errors.New(name + " inválido (use RFC3339 ou AAAA-MM-DD)")
+			}
+			*target = &t
+		}
+	}
+
+	return filter, nil
+}
+
+func parseFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// BulkUserAction
+// @Summary Ação em lote sobre usuários
+// @Description Ativa, desativa ou troca o papel de vários usuários numa única transação (ou todos mudam, ou nenhum). activate/deactivate exigem users:manage; change_role exige users:update_role e as mesmas regras de PUT /users/{id}/role.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BulkUserActionRequest true "Ação e usuários"
+// @Success 200 {object} Response{data=BulkUserActionResult}
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Router /users/bulk [post]
+func (h *Handler) BulkUserAction(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req BulkUserActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	permission := rbac.UsersManage
+	if req.Action == BulkChangeRole {
+		permission = rbac.UsersUpdateRole
+	}
+	if !h.hasPermission(claims, permission) {
+		http.Error(w, "Forbidden: access denied", http.StatusForbidden)
+		return
+	}
+
+	result, err := h.service.BulkAction(claims.UserID, claims.RoleID, req, middleware.RemoteIP(r))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "erro ao aplicar ação em lote"
+		switch {
+		case errors.Is(err, ErrRoleNotGrantable), errors.Is(err, ErrBulkSelf):
+			status, message = http.StatusForbidden, err.Error()
+		case errors.Is(err, ErrInvalidBulkAction), errors.Is(err, ErrBulkRoleNotFound), errors.Is(err, ErrBulkUserNotFound):
+			status, message = http.StatusBadRequest, err.Error()
+		default:
+			log.Printf("❌ Erro na ação em lote %s: %v", req.Action, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: message})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{
+		Message: "ação em lote aplicada",
+		Data:    result,
+	})
+}
@@ -0,0 +1,234 @@
+package users
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// listSortColumns traduz sort=... para a expressão usada no ORDER BY e
+// no cursor. created_at ordena pelo próprio id (Snowflake é ordenado no
+// tempo); last_login_at nulo vai para o fim em ordem decrescente.
+var listSortColumns = map[string]string{
+	SortName:        "name",
+	SortEmail:       "email",
+	SortLastLoginAt: "COALESCE(last_login_at, '-infinity'::timestamp)",
+}
+
+// listWhere monta o WHERE dos filtros de GET /users (sem o cursor).
+// Contas anonimizadas não aparecem no console.
+func listWhere(filter ListUsersFilter) ([]string, []any) {
+	conditions := []string{"anonymized_at IS NULL"}
+	var args []any
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Query != "" {
+		add("(name ILIKE $%[1]d OR email ILIKE $%[1]d)", "%"+escapeLike(filter.Query)+"%")
+	}
+	if filter.RoleID != nil {
+		add("role_id = $%d", *filter.RoleID)
+	}
+	if filter.IsActive != nil {
+		add("is_active = $%d", *filter.IsActive)
+	}
+	if filter.IsEmailVerified != nil {
+		add("is_email_verified = $%d", *filter.IsEmailVerified)
+	}
+	if filter.CreatedFrom != nil {
+		add("created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		add("created_at < $%d", *filter.CreatedTo)
+	}
+	if filter.LastLoginFrom != nil {
+		add("last_login_at >= $%d", *filter.LastLoginFrom)
+	}
+	if filter.LastLoginTo != nil {
+		add("last_login_at < $%d", *filter.LastLoginTo)
+	}
+
+	return conditions, args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// CountUsers devolve o total de usuários que passam nos filtros.
+func (r *Repository) CountUsers(filter ListUsersFilter) (int, error) {
+	conditions, args := listWhere(filter)
+
+	var total int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM users WHERE `+strings.Join(conditions, " AND "),
+		args...,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao contar usuários: %w", err)
+	}
+	return total, nil
+}
+
+// ListUsersPage busca até filter.Limit+1 usuários depois do cursor (a
+// linha extra só indica que existe próxima página).
+func (r *Repository) ListUsersPage(filter ListUsersFilter) ([]UserResponse, error) {
+	conditions, args := listWhere(filter)
+
+	direction, comparison := "ASC", ">"
+	if filter.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	orderBy := "id " + direction
+	column, sorted := listSortColumns[filter.Sort]
+	if sorted {
+		orderBy = fmt.Sprintf("%s %s, id %s", column, direction, direction)
+	}
+
+	if filter.after != nil {
+		if sorted {
+			cast := ""
+			if filter.Sort == SortLastLoginAt {
+				cast = "::timestamp"
+			}
+			args = append(args, filter.after.Value, filter.after.ID)
+			conditions = append(conditions, fmt.Sprintf(
+				"(%s, id) %s ($%d%s, $%d::bigint)", column, comparison, len(args)-1, cast, len(args),
+			))
+		} else {
+			args = append(args, filter.after.ID)
+			conditions = append(conditions, fmt.Sprintf("id %s $%d::bigint", comparison, len(args)))
+		}
+	}
+
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT id, email, name, role_id, is_active, is_email_verified, created_at, last_login_at
+		FROM users
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), orderBy, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar usuários: %w", err)
+	}
+	defer rows.Close()
+
+	users := []UserResponse{}
+	for rows.Next() {
+		var u UserResponse
+		var lastLogin sql.NullTime
+		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.RoleID, &u.IsActive, &u.IsEmailVerified, &u.CreatedAt, &lastLogin); err != nil {
+			return nil, fmt.Errorf("erro ao scanear usuário: %w", err)
+		}
+		if lastLogin.Valid {
+			u.LastLoginAt = &lastLogin.Time
+		}
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos resultados: %w", err)
+	}
+
+	return users, nil
+}
+
+// lockUsers trava (FOR UPDATE) os usuários do lote e devolve o papel
+// atual de cada um. Falha se algum não existir ou já estiver anonimizado.
+func lockUsers(tx *sql.Tx, userIDs []string) (map[string]int, error) {
+	rows, err := tx.Query(`
+		SELECT id, role_id FROM users
+		WHERE id = ANY($1::bigint[]) AND anonymized_at IS NULL
+		FOR UPDATE
+	`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuários do lote: %w", err)
+	}
+	defer rows.Close()
+
+	roles := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var id string
+		var roleID int
+		if err := rows.Scan(&id, &roleID); err != nil {
+			return nil, err
+		}
+		roles[id] = roleID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(roles) != len(userIDs) {
+		return nil, ErrBulkUserNotFound
+	}
+	return roles, nil
+}
+
+// BulkSetActive ativa/desativa os usuários numa única transação: ou
+// todos mudam, ou nenhum. check recebe o papel atual de cada um (já
+// travados) e pode abortar o lote.
+func (r *Repository) BulkSetActive(userIDs []string, active bool, check func(current map[string]int) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := lockUsers(tx, userIDs)
+	if err != nil {
+		return err
+	}
+	if err := check(current); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users SET is_active = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($2::bigint[])
+	`, active, pq.Array(userIDs)); err != nil {
+		return fmt.Errorf("erro ao atualizar usuários: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// BulkUpdateRole troca o papel dos usuários numa única transação. check
+// recebe o papel atual de cada um (já travados) e pode abortar o lote.
+// Devolve os papéis anteriores.
+func (r *Repository) BulkUpdateRole(userIDs []string, roleID int, check func(previous map[string]int) error) (map[string]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := lockUsers(tx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := check(previous); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users SET role_id = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($2::bigint[])
+	`, roleID, pq.Array(userIDs)); err != nil {
+		return nil, fmt.Errorf("erro ao alterar papel dos usuários: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
@@ -0,0 +1,200 @@
+package users
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"loginbackend/internal/audit"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+var (
+	ErrInvalidCursor     = errors.New("cursor inválido")
+	ErrInvalidSort       = errors.New("sort deve ser created_at, name, email ou last_login_at")
+	ErrInvalidBulkAction = errors.New("dados inválidos")
+	ErrBulkRoleNotFound  = errors.New("papel não encontrado")
+	ErrBulkUserNotFound  = errors.New("um ou mais usuários do lote não existem")
+	ErrBulkSelf          = errors.New("não é possível aplicar ações em lote à própria conta")
+)
+
+// ListUsers devolve uma página de usuários (paginação por cursor) e o
+// total que passa nos filtros.
+func (s *Service) ListUsers(filter ListUsersFilter) (*UserPage, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if !validListSort(filter.Sort) {
+		return nil, 0, ErrInvalidSort
+	}
+	if filter.Sort == "" {
+		filter.Sort = SortCreatedAt
+	}
+
+	if filter.Cursor != "" {
+		after, err := decodeListCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Cursor de outra ordenação pularia ou repetiria linhas (e o valor
+		// nem sempre é do tipo da coluna nova).
+		if after.Sort != filter.Sort || after.Desc != filter.Desc {
+			return nil, 0, ErrInvalidCursor
+		}
+		filter.after = after
+	}
+
+	total, err := s.repo.CountUsers(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, err := s.repo.ListUsersPage(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page := &UserPage{Users: users}
+	if len(users) > filter.Limit {
+		page.Users = users[:filter.Limit]
+		page.NextCursor = encodeListCursor(filter.Sort, filter.Desc, page.Users[filter.Limit-1])
+	}
+
+	return page, total, nil
+}
+
+func validListSort(sort string) bool {
+	_, ok := listSortColumns[sort]
+	return ok || sort == "" || sort == SortCreatedAt
+}
+
+func encodeListCursor(sort string, desc bool, last UserResponse) string {
+	cursor := listCursor{Sort: sort, Desc: desc, ID: last.ID}
+	switch sort {
+	case SortName:
+		cursor.Value = last.Name
+	case SortEmail:
+		cursor.Value = last.Email
+	case SortLastLoginAt:
+		cursor.Value = "-infinity"
+		if last.LastLoginAt != nil {
+			cursor.Value = last.LastLoginAt.Format(time.RFC3339Nano)
+		}
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(encoded string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// BulkAction ativa, desativa ou troca o papel de vários usuários numa
+// única transação. Cada usuário do lote segue as mesmas regras de Update
+// e ChangeRole (papéis com permissões que o ator não tem ficam fora do
+// alcance); se um falhar, nenhum muda.
+func (s *Service) BulkAction(actorID string, actorRoleID int, req BulkUserActionRequest, ipAddress string) (*BulkUserActionResult, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBulkAction, err)
+	}
+
+	userIDs := make([]string, 0, len(req.UserIDs))
+	seen := make(map[string]bool, len(req.UserIDs))
+	for _, id := range req.UserIDs {
+		if id == actorID {
+			return nil, ErrBulkSelf
+		}
+		if !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	switch req.Action {
+	case BulkActivate, BulkDeactivate:
+		active := req.Action == BulkActivate
+		err := s.repo.BulkSetActive(userIDs, active, func(current map[string]int) error {
+			roleIDs := make([]int, 0, len(current))
+			for _, roleID := range current {
+				roleIDs = append(roleIDs, roleID)
+			}
+			return s.checkRolesGrantable(actorRoleID, roleIDs...)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		action := audit.ActionUserActivated
+		if !active {
+			action = audit.ActionUserDeactivated
+		}
+		for _, id := range userIDs {
+			if !active {
+				if err := s.sessions.RevokeAllSessions(id, "deactivated"); err != nil {
+					log.Printf("⚠️ Erro ao revogar sessões de %s: %v", id, err)
+				}
+			}
+			s.logAudit(actorID, id, action, ipAddress, map[string]any{"bulk": true})
+		}
+
+	case BulkChangeRole:
+		if req.RoleID <= 0 {
+			return nil, fmt.Errorf("%w: role_id é obrigatório para change_role", ErrInvalidBulkAction)
+		}
+		exists, err := s.repo.RoleExists(req.RoleID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrBulkRoleNotFound
+		}
+
+		previous, err := s.repo.BulkUpdateRole(userIDs, req.RoleID, func(previous map[string]int) error {
+			roleIDs := []int{req.RoleID}
+			for _, roleID := range previous {
+				roleIDs = append(roleIDs, roleID)
+			}
+			return s.checkRolesGrantable(actorRoleID, roleIDs...)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range userIDs {
+			if previous[id] == req.RoleID {
+				continue
+			}
+			// Mesmo motivo de ChangeRole: o role_id viaja no access token
+			if err := s.sessions.InvalidateAccessTokens(id); err != nil {
+				log.Printf("⚠️ Erro ao invalidar tokens de %s: %v", id, err)
+			}
+			s.logAudit(actorID, id, audit.ActionUserRoleChanged, ipAddress, map[string]any{
+				"previous_role_id": previous[id],
+				"role_id":          req.RoleID,
+				"bulk":             true,
+			})
+		}
+	}
+
+	return &BulkUserActionResult{Action: req.Action, Affected: len(userIDs)}, nil
+}
@@ -2,9 +2,14 @@ package users
 
 import (
 	"encoding/json"
-	"loginbackend/config"
+	"errors"
+	"log"
+	"loginbackend/internal/http/middleware"
 	httpresponse "loginbackend/internal/http/response"
+	"loginbackend/pkg/rbac"
+	"loginbackend/pkg/storage"
 	"loginbackend/pkg/uploader"
+	"loginbackend/pkg/utils"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -12,10 +17,17 @@ import (
 
 type Handler struct {
 	service *Service
+	storage storage.Storage
 }
 
-func NewHandler(service *Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service *Service, store storage.Storage) *Handler {
+	return &Handler{service: service, storage: store}
+}
+
+// hasPermission confere uma permissão de sistema do papel de quem chama
+// (mesmo resolvedor de papéis usado pelo service).
+func (h *Handler) hasPermission(claims *utils.TokenClaims, permission string) bool {
+	return middleware.HasSystemPermission(h.service.roles, claims, permission)
 }
 
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
@@ -28,8 +40,12 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.service.Create(req)
 	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrSignupClosed) || errors.Is(err, ErrSignupDomain) {
+			status = http.StatusForbidden
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
 		return
 	}
@@ -42,19 +58,6 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.service.List()
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar usuários"})
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(httpresponse.Response{Data: users})
-}
-
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	// ✅ CORREÇÃO: ID agora é string (Snowflake ID)
 	userID := chi.URLParam(r, "id")
@@ -82,10 +85,17 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.Update(userID, req)
+	claims := middleware.GetUserFromContext(r.Context())
+	canManage := h.hasPermission(claims, rbac.UsersManage)
+
+	user, err := h.service.Update(claims.UserID, claims.RoleID, userID, req, canManage)
 	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrAdminOnlyField) || errors.Is(err, ErrRoleChangeNotHere) || errors.Is(err, ErrRoleNotGrantable) {
+			status = http.StatusForbidden
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
 		return
 	}
@@ -97,13 +107,59 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ChangeRole
+// @Summary Alterar papel do usuário
+// @Description Troca o papel (role_id) de um usuário. Exige users:update_role e todas as permissões do papel atual e do novo. Auditado.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body ChangeRoleRequest true "Novo papel"
+// @Success 200 {object} Response
+// @Failure 403 {object} Response
+// @Router /users/{id}/role [put]
+func (h *Handler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RoleID <= 0 {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.ChangeRole(claims.UserID, claims.RoleID, chi.URLParam(r, "id"), req.RoleID, middleware.RemoteIP(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrRoleNotGrantable) || errors.Is(err, ErrOwnRoleChange) {
+			status = http.StatusForbidden
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "papel alterado", Data: user})
+}
+
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	// ✅ CORREÇÃO: ID agora é string (Snowflake ID)
 	userID := chi.URLParam(r, "id")
+	claims := middleware.GetUserFromContext(r.Context())
 
-	if err := h.service.Delete(userID); err != nil {
+	if err := h.service.Delete(claims.UserID, claims.RoleID, userID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrRoleNotGrantable) {
+			status = http.StatusForbidden
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
 		return
 	}
@@ -141,7 +197,7 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 
 // UploadAvatar
 // @Summary Atualizar avatar do usuário
-// @Description Recebe uma imagem via multipart/form-data e atualiza o perfil
+// @Description Recebe uma imagem (jpg ou png, até 5MB e 6000px) via multipart/form-data e gera as variantes 64/256/512 (quadradas, sem EXIF, com o hash do conteúdo no nome)
 // @Tags users
 // @Accept multipart/form-data
 // @Produce json
@@ -153,6 +209,7 @@ func (h *Handler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 
 	// Limite de 5MB
+	r.Body = http.MaxBytesReader(w, r.Body, 5<<20)
 	r.ParseMultipartForm(5 << 20)
 
 	file, _, err := r.FormFile("avatar")
@@ -162,21 +219,28 @@ func (h *Handler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	cfg := config.Load()
-
-	avatarURL, err := uploader.UploadProfilePicture(userID, file, nil, cfg)
+	avatar, err := uploader.UploadAvatar(h.storage, userID, file)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, uploader.ErrInvalidImage) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
 	// Atualiza no banco (Service)
-	updatedUser, err := h.service.UpdateAvatar(userID, avatarURL)
+	updatedUser, err := h.service.UpdateAvatar(userID, avatar.URL(), avatar.Variants)
 	if err != nil {
 		http.Error(w, "Erro ao atualizar banco", http.StatusInternalServerError)
 		return
 	}
 
+	// O banco já aponta para o avatar novo: os arquivos anteriores podem ir
+	if err := uploader.PruneAvatars(h.storage, userID, avatar.Hash); err != nil {
+		log.Printf("⚠️ Erro ao remover avatares antigos de %s: %v", userID, err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(httpresponse.Response{
 		Message: "Avatar atualizado",
@@ -0,0 +1,198 @@
+package users
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+)
+
+// maxImportBody limita o corpo da importação (CSV, multipart ou JSON).
+const maxImportBody = 5 << 20
+
+// ImportUsers
+// @Summary Importar usuários em lote
+// @Description Aceita CSV (text/csv ou multipart, campo "file") com cabeçalho name,email[,password][,role_id], ou JSON. Cada linha segue as regras do cadastro; linhas sem senha recebem um link para o usuário definir a própria. Tudo ou nada: com qualquer erro, nada é criado e o relatório traz os erros por linha.
+// @Tags users
+// @Accept json
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param dry_run query bool false "Só valida, sem criar"
+// @Param request body ImportUsersRequest false "Usuários (JSON)"
+// @Param file formData file false "Arquivo CSV"
+// @Success 200 {object} Response{data=ImportReport}
+// @Failure 400 {object} Response{data=ImportReport}
+// @Router /users/import [post]
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBody)
+	rows, err := readImportRows(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	report, err := h.service.ImportUsers(claims.UserID, claims.RoleID, rows, dryRun, middleware.RemoteIP(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case len(report.Errors) > 0:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{
+			Error: "importação não realizada: corrija as linhas com erro",
+			Data:  report,
+		})
+	case dryRun:
+		json.NewEncoder(w).Encode(httpresponse.Response{Message: "nenhum erro encontrado (dry run)", Data: report})
+	default:
+		json.NewEncoder(w).Encode(httpresponse.Response{Message: "usuários importados", Data: report})
+	}
+}
+
+// readImportRows lê as linhas conforme o Content-Type da requisição.
+func readImportRows(r *http.Request) ([]ImportUserRow, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "text/csv":
+		return parseImportCSV(r.Body)
+
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxImportBody); err != nil {
+			return nil, errors.New("arquivo inválido ou maior que 5MB")
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("arquivo obrigatório (campo file)")
+		}
+		defer file.Close()
+		return parseImportCSV(file)
+
+	default:
+		var req ImportUsersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, errors.New("dados inválidos")
+		}
+		return req.Users, nil
+	}
+}
+
+// parseImportCSV lê o CSV da importação. O cabeçalho é obrigatório; a
+// ordem das colunas é livre e password/role_id são opcionais.
+func parseImportCSV(input io.Reader) ([]ImportUserRow, error) {
+	reader := csv.NewReader(input)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("CSV vazio ou inválido")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("coluna obrigatória ausente no cabeçalho: %s", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []ImportUserRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV inválido: %w", err)
+		}
+
+		row := ImportUserRow{
+			Name:     field(record, "name"),
+			Email:    field(record, "email"),
+			Password: field(record, "password"),
+		}
+		if roleID := field(record, "role_id"); roleID != "" {
+			if row.RoleID, err = strconv.Atoi(roleID); err != nil {
+				return nil, fmt.Errorf("linha %d: role_id inválido", line)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// ExportUsers
+// @Summary Exportar diretório de usuários (CSV)
+// @Description Mesmos filtros e ordenação de GET /users, sem paginação.
+// @Tags users
+// @Produce text/csv
+// @Security BearerAuth
+// @Param q query string false "Busca em nome e email"
+// @Param role_id query int false "Papel"
+// @Param is_active query bool false "Ativo"
+// @Param is_email_verified query bool false "Email verificado"
+// @Param sort query string false "created_at (padrão), name, email, last_login_at"
+// @Param order query string false "asc ou desc (padrão)"
+// @Success 200 {file} file
+// @Failure 400 {object} Response
+// @Router /users/export [get]
+func (h *Handler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListUsersFilter(r.URL.Query())
+	if err == nil {
+		if !validListSort(filter.Sort) {
+			err = ErrInvalidSort
+		}
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="usuarios-%s.csv"`, time.Now().Format("20060102")))
+
+	// O CSV vai sendo escrito página a página: um erro no meio do caminho
+	// já não tem como virar status HTTP.
+	if err := h.service.ExportUsersCSV(filter, w); err != nil {
+		log.Printf("❌ Erro ao exportar usuários: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package users
+
+import (
+	"fmt"
+	"strings"
+
+	"loginbackend/features/shared/models"
+
+	"github.com/lib/pq"
+)
+
+// ExistingEmails devolve, em minúsculas, quais dos emails já têm conta.
+func (r *Repository) ExistingEmails(emails []string) (map[string]bool, error) {
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT LOWER(email) FROM users WHERE LOWER(email) = ANY($1)`,
+		pq.Array(lowered),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar emails: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		existing[email] = true
+	}
+	return existing, rows.Err()
+}
+
+// CreateBatch cria todos os usuários numa única transação.
+func (r *Repository) CreateBatch(users []models.User) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO users
+		(id, email, name, password_hash, role_id, is_active, is_email_verified)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`)
+	if err != nil {
+		return fmt.Errorf("erro ao preparar importação: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, user := range users {
+		if _, err := stmt.Exec(
+			user.ID, user.Email, user.Name, user.PasswordHash,
+			user.RoleID, user.IsActive, user.IsEmailVerified,
+		); err != nil {
+			return fmt.Errorf("erro ao criar usuário %s: %w", user.Email, err)
+		}
+	}
+
+	return tx.Commit()
+}
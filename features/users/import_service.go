@@ -0,0 +1,246 @@
+package users
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// maxImportRows limita o tamanho de uma importação (cada senha passa pelo
+// hash, que é caro de propósito).
+const maxImportRows = 500
+
+// importRow é uma linha já validada, pronta para virar usuário.
+type importRow struct {
+	req     CreateUserRequest
+	invited bool
+}
+
+// ImportUsers cria usuários em lote. Cada linha passa pelas mesmas regras
+// de CreateUserRequest (e pela política de senha); o papel, se informado,
+// precisa ser atribuível por quem importa (mesma regra de ChangeRole).
+// É tudo ou nada: com qualquer erro, nenhuma conta é criada e o relatório
+// traz os problemas de cada linha.
+func (s *Service) ImportUsers(actorID string, actorRoleID int, rows []ImportUserRow, dryRun bool, ipAddress string) (*ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("nenhum usuário para importar")
+	}
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("máximo de %d usuários por importação", maxImportRows)
+	}
+
+	emails := make([]string, len(rows))
+	for i := range rows {
+		emails[i] = strings.TrimSpace(rows[i].Email)
+	}
+	existing, err := s.repo.ExistingEmails(emails)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: dryRun, Total: len(rows)}
+	valid := make([]importRow, 0, len(rows))
+	seen := make(map[string]int, len(rows))
+	roleChecks := make(map[int]error)
+
+	for i, row := range rows {
+		req := CreateUserRequest{
+			Name:     strings.TrimSpace(row.Name),
+			Email:    emails[i],
+			Password: row.Password,
+			RoleID:   row.RoleID,
+		}
+		if req.RoleID == 0 {
+			req.RoleID = 2 // USER
+		}
+		invited := req.Password == ""
+
+		var problems []string
+		if err := s.validate.Struct(req); err != nil {
+			var fieldErrors validator.ValidationErrors
+			if errors.As(err, &fieldErrors) {
+				for _, fe := range fieldErrors {
+					if invited && fe.Field() == "Password" {
+						continue
+					}
+					problems = append(problems, fmt.Sprintf("%s inválido (%s)", strings.ToLower(fe.Field()), fe.Tag()))
+				}
+			} else {
+				problems = append(problems, err.Error())
+			}
+		}
+
+		if !invited {
+			if err := s.policy.Validate(req.Password); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+
+		email := strings.ToLower(req.Email)
+		if first, ok := seen[email]; ok && email != "" {
+			problems = append(problems, fmt.Sprintf("email repetido no arquivo (linha %d)", first))
+		} else {
+			seen[email] = i + 1
+		}
+		if existing[email] {
+			problems = append(problems, "email já cadastrado")
+		}
+
+		roleErr, checked := roleChecks[req.RoleID]
+		if !checked {
+			roleErr = s.checkAssignableRole(actorRoleID, req.RoleID)
+			roleChecks[req.RoleID] = roleErr
+		}
+		if roleErr != nil {
+			problems = append(problems, roleErr.Error())
+		}
+
+		if len(problems) > 0 {
+			report.Errors = append(report.Errors, ImportRowError{Row: i + 1, Email: req.Email, Errors: problems})
+			continue
+		}
+
+		valid = append(valid, importRow{req: req, invited: invited})
+		if invited {
+			report.Invited++
+		} else {
+			report.Created++
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		report.Created, report.Invited = 0, 0
+		return report, nil
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	users := make([]models.User, 0, len(valid))
+	for _, row := range valid {
+		password := row.req.Password
+		if row.invited {
+			// Senha aleatória e descartada: o usuário define a dele pelo link
+			if password, err = utils.GenerateRefreshToken(); err != nil {
+				return nil, err
+			}
+		}
+
+		hash, err := utils.HashPassword(password)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao gerar hash da senha: %w", err)
+		}
+
+		users = append(users, models.User{
+			ID:           utils.GenerateSnowflakeID(),
+			Name:         row.req.Name,
+			Email:        row.req.Email,
+			PasswordHash: hash,
+			RoleID:       row.req.RoleID,
+			IsActive:     true,
+		})
+	}
+
+	if err := s.repo.CreateBatch(users); err != nil {
+		return nil, err
+	}
+
+	for i, user := range users {
+		if valid[i].invited {
+			// O link de definição de senha também verifica o email
+			if err := s.verifier.SendAccountSetup(user.ID, user.Email, user.Name); err != nil {
+				log.Printf("⚠️ Erro ao enviar convite para %s: %v", user.Email, err)
+			}
+		} else {
+			s.sendVerification(&users[i])
+		}
+
+		s.logAudit(actorID, user.ID, audit.ActionUserImported, ipAddress, map[string]any{
+			"role_id": user.RoleID,
+			"invited": valid[i].invited,
+		})
+	}
+
+	return report, nil
+}
+
+// checkAssignableRole confere se o papel existe e se quem atribui pode atribuí-lo.
+func (s *Service) checkAssignableRole(actorRoleID, roleID int) error {
+	exists, err := s.repo.RoleExists(roleID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("papel não encontrado")
+	}
+	return s.checkRolesGrantable(actorRoleID, roleID)
+}
+
+// exportColumns é o cabeçalho do CSV do diretório de usuários.
+var exportColumns = []string{"id", "name", "email", "role_id", "is_active", "is_email_verified", "created_at", "last_login_at"}
+
+// ExportUsersCSV escreve em w o diretório de usuários (com os mesmos
+// filtros e ordenação de ListUsers), página a página.
+func (s *Service) ExportUsersCSV(filter ListUsersFilter, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+
+	filter.Limit = maxListLimit
+	filter.Cursor = ""
+	for {
+		page, _, err := s.ListUsers(filter)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range page.Users {
+			lastLogin := ""
+			if u.LastLoginAt != nil {
+				lastLogin = u.LastLoginAt.UTC().Format(time.RFC3339)
+			}
+			record := []string{
+				u.ID,
+				csvSafe(u.Name),
+				csvSafe(u.Email),
+				strconv.Itoa(u.RoleID),
+				strconv.FormatBool(u.IsActive),
+				strconv.FormatBool(u.IsEmailVerified),
+				u.CreatedAt.UTC().Format(time.RFC3339),
+				lastLogin,
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSafe neutraliza fórmulas (=, +, -, @) em campos digitados pelo
+// usuário, para a planilha não executá-las ao abrir o arquivo.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
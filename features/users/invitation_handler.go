@@ -0,0 +1,151 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+	"loginbackend/pkg/rbac"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateInvitation
+// @Summary Convidar para criar conta
+// @Description Envia um código de uso único por email. Com users:invite, qualquer time e papel atribuível; o admin de um time convida só para o próprio time (papel USER). Um convite novo substitui o pendente do mesmo email.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateInvitationRequest true "Convite"
+// @Success 201 {object} Response{data=Invitation}
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 409 {object} Response
+// @Router /users/invitations [post]
+func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	canInvite := h.hasPermission(claims, rbac.UsersInvite)
+	invitation, err := h.service.CreateInvitation(claims.UserID, claims.RoleID, canInvite, req, middleware.RemoteIP(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrInvitationForbidden) || errors.Is(err, ErrRoleNotGrantable) {
+			status = http.StatusForbidden
+		}
+		if errors.Is(err, ErrInvitationConflict) {
+			status = http.StatusConflict
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "convite enviado", Data: invitation})
+}
+
+// ListInvitations
+// @Summary Listar convites
+// @Description Com users:invite, todos; sem, apenas os enviados por quem chama.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, accepted, revoked ou expired"
+// @Success 200 {object} Response{data=[]Invitation}
+// @Failure 400 {object} Response
+// @Router /users/invitations [get]
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	canInvite := h.hasPermission(claims, rbac.UsersInvite)
+	invitations, err := h.service.ListInvitations(claims.UserID, canInvite, r.URL.Query().Get("status"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: invitations})
+}
+
+// RevokeInvitation
+// @Summary Revogar convite
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param invitationID path string true "ID do convite"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Router /users/invitations/{invitationID} [delete]
+func (h *Handler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	canInvite := h.hasPermission(claims, rbac.UsersInvite)
+	if err := h.service.RevokeInvitation(claims.UserID, canInvite, chi.URLParam(r, "invitationID"), middleware.RemoteIP(r)); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "convite revogado"})
+}
+
+// AcceptInvitation
+// @Summary Aceitar convite
+// @Description Cria a conta com o email do convite (já verificado) e, se for o caso, adiciona ao time. Depois, é só fazer login.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body AcceptInvitationRequest true "Código, nome e senha"
+// @Success 201 {object} Response
+// @Failure 400 {object} Response
+// @Router /users/invitations/accept [post]
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.AcceptInvitation(req, middleware.RemoteIP(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(httpresponse.Response{
+		Message: "conta criada com sucesso",
+		Data:    user,
+	})
+}
@@ -0,0 +1,242 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"loginbackend/features/shared/models"
+)
+
+// ErrInvitationInvalid cobre código inexistente, já usado, revogado ou vencido.
+var ErrInvitationInvalid = errors.New("convite inválido ou expirado")
+
+// ErrInvitationConflict: já há um convite pendente para o email, enviado
+// por outra pessoa, que quem convida não pode substituir.
+var ErrInvitationConflict = errors.New("já existe um convite pendente para este email")
+
+const invitationColumns = `
+	id, email, role_id, team_id, team_role, invited_by, status,
+	expires_at, accepted_at, accepted_user_id, revoked_at, created_at`
+
+func scanInvitation(scanner interface{ Scan(...any) error }) (*Invitation, error) {
+	var inv Invitation
+	var teamID, teamRole, acceptedUserID sql.NullString
+	var acceptedAt, revokedAt sql.NullTime
+
+	err := scanner.Scan(
+		&inv.ID, &inv.Email, &inv.RoleID, &teamID, &teamRole, &inv.InvitedBy, &inv.Status,
+		&inv.ExpiresAt, &acceptedAt, &acceptedUserID, &revokedAt, &inv.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if teamID.Valid {
+		inv.TeamID = &teamID.String
+	}
+	if teamRole.Valid {
+		inv.TeamRole = &teamRole.String
+	}
+	if acceptedAt.Valid {
+		inv.AcceptedAt = &acceptedAt.Time
+	}
+	if acceptedUserID.Valid {
+		inv.AcceptedUserID = &acceptedUserID.String
+	}
+	if revokedAt.Valid {
+		inv.RevokedAt = &revokedAt.Time
+	}
+	if inv.Status == InvitationPending && time.Now().After(inv.ExpiresAt) {
+		inv.Status = InvitationExpired
+	}
+
+	return &inv, nil
+}
+
+// CreateInvitation grava o convite, revogando um pendente anterior para
+// o mesmo email (só o último código enviado vale). Sem replaceAny, só
+// substitui convites do próprio inv.InvitedBy: um convite vigente de outra
+// pessoa devolve ErrInvitationConflict.
+func (r *Repository) CreateInvitation(inv Invitation, codeHash string, replaceAny bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	if !replaceAny {
+		var others bool
+		if err := tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM invitations
+				WHERE LOWER(email) = LOWER($1) AND status = 'pending'
+				  AND expires_at > CURRENT_TIMESTAMP AND invited_by IS DISTINCT FROM $2
+			)
+		`, inv.Email, inv.InvitedBy).Scan(&others); err != nil {
+			return fmt.Errorf("erro ao verificar convite anterior: %w", err)
+		}
+		if others {
+			return ErrInvitationConflict
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE invitations SET status = 'revoked', revoked_at = CURRENT_TIMESTAMP
+		WHERE LOWER(email) = LOWER($1) AND status = 'pending'
+	`, inv.Email); err != nil {
+		return fmt.Errorf("erro ao substituir convite anterior: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO invitations (id, email, code_hash, role_id, team_id, team_role, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, inv.ID, inv.Email, codeHash, inv.RoleID, inv.TeamID, inv.TeamRole, inv.InvitedBy, inv.ExpiresAt); err != nil {
+		return fmt.Errorf("erro ao criar convite: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) FindInvitation(id string) (*Invitation, error) {
+	inv, err := scanInvitation(r.db.QueryRow(
+		`SELECT `+invitationColumns+` FROM invitations WHERE id = $1`, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar convite: %w", err)
+	}
+	return inv, nil
+}
+
+func (r *Repository) FindInvitationByCode(codeHash string) (*Invitation, error) {
+	inv, err := scanInvitation(r.db.QueryRow(
+		`SELECT `+invitationColumns+` FROM invitations WHERE code_hash = $1`, codeHash,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar convite: %w", err)
+	}
+	return inv, nil
+}
+
+// ListInvitations lista os convites, do mais novo para o mais antigo.
+// invitedBy vazio = todos; status vazio = qualquer situação.
+func (r *Repository) ListInvitations(invitedBy, status string) ([]Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE ($1 = '' OR invited_by::text = $1)`
+	switch status {
+	case InvitationExpired:
+		query += ` AND status = 'pending' AND expires_at <= CURRENT_TIMESTAMP`
+	case InvitationPending:
+		query += ` AND status = 'pending' AND expires_at > CURRENT_TIMESTAMP`
+	case InvitationAccepted, InvitationRevoked:
+		query += ` AND status = '` + status + `'`
+	}
+	query += ` ORDER BY created_at DESC LIMIT 500`
+
+	rows, err := r.db.Query(query, invitedBy)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar convites: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao scanear convite: %w", err)
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, rows.Err()
+}
+
+// RevokeInvitation revoga um convite ainda pendente.
+func (r *Repository) RevokeInvitation(id string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE invitations SET status = 'revoked', revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar convite: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// AcceptInvitation cria o usuário, consome o convite e, se o convite for
+// de um time, adiciona-o como membro — tudo na mesma transação.
+func (r *Repository) AcceptInvitation(inv *Invitation, user models.User) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO users
+		(id, email, name, password_hash, role_id, is_active, is_email_verified)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, user.ID, user.Email, user.Name, user.PasswordHash, user.RoleID, user.IsActive, user.IsEmailVerified); err != nil {
+		return fmt.Errorf("erro ao criar usuário no banco de dados: %w", err)
+	}
+
+	// Condicional: de dois aceites simultâneos do mesmo código, só um passa
+	result, err := tx.Exec(`
+		UPDATE invitations
+		SET status = 'accepted', accepted_at = CURRENT_TIMESTAMP, accepted_user_id = $2
+		WHERE id = $1 AND status = 'pending' AND expires_at > CURRENT_TIMESTAMP
+	`, inv.ID, user.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao aceitar convite: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return ErrInvitationInvalid
+	}
+
+	if inv.TeamID != nil {
+		teamRole := "Member"
+		if inv.TeamRole != nil {
+			teamRole = *inv.TeamRole
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)
+			ON CONFLICT (team_id, user_id) DO NOTHING
+		`, *inv.TeamID, user.ID, teamRole); err != nil {
+			return fmt.Errorf("erro ao adicionar usuário ao time: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) TeamExists(teamID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM teams WHERE id = $1)`, teamID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar time: %w", err)
+	}
+	return exists, nil
+}
+
+// IsTeamAdmin indica se o usuário é Admin do time.
+func (r *Repository) IsTeamAdmin(userID, teamID string) (bool, error) {
+	var admin bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2 AND role = 'Admin'
+		)
+	`, teamID, userID).Scan(&admin)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar admin do time: %w", err)
+	}
+	return admin, nil
+}
@@ -0,0 +1,258 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/mailer"
+	"loginbackend/pkg/utils"
+)
+
+var (
+	ErrSignupClosed        = errors.New("cadastro apenas por convite")
+	ErrSignupDomain        = errors.New("cadastro não permitido para o domínio deste email")
+	ErrInvitationForbidden = errors.New("sem permissão para convidar: é preciso users:invite ou ser admin do time (sem alterar o papel)")
+)
+
+// SignupConfig controla o cadastro público e os convites.
+type SignupConfig struct {
+	Mode             string // open, invite ou domain
+	AllowedDomains   []string
+	InvitationExpiry time.Duration
+
+	Mailer mailer.Sender
+	AppURL string
+}
+
+// SetSignup define o modo de cadastro e o envio de convites (sem ele,
+// o cadastro é aberto e convites não podem ser enviados).
+func (s *Service) SetSignup(cfg SignupConfig) {
+	if cfg.InvitationExpiry == 0 {
+		cfg.InvitationExpiry = 7 * 24 * time.Hour
+	}
+	s.signup = cfg
+}
+
+// checkSignup aplica SIGNUP_MODE ao cadastro público (POST /users e
+// login social). Convites e importação não passam por aqui.
+func (s *Service) checkSignup(email string) error {
+	switch s.signup.Mode {
+	case SignupInvite:
+		return ErrSignupClosed
+	case SignupDomain:
+		at := strings.LastIndex(email, "@")
+		domain := strings.ToLower(email[at+1:])
+		for _, allowed := range s.signup.AllowedDomains {
+			if domain == strings.ToLower(allowed) {
+				return nil
+			}
+		}
+		return ErrSignupDomain
+	}
+	return nil
+}
+
+// CreateInvitation envia um convite. Quem tem users:invite (canInvite)
+// convida para qualquer time e papel que possa atribuir; o admin de um
+// time só convida para o próprio time, com o papel padrão (USER).
+func (s *Service) CreateInvitation(actorID string, actorRoleID int, canInvite bool, req CreateInvitationRequest, ipAddress string) (*Invitation, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("dados inválidos: %w", err)
+	}
+	if s.signup.Mailer == nil {
+		return nil, errors.New("envio de convites não configurado")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	roleID := req.RoleID
+	if roleID == 0 {
+		roleID = 2 // USER
+	}
+
+	if !canInvite {
+		if req.TeamID == "" || roleID != 2 {
+			return nil, ErrInvitationForbidden
+		}
+		admin, err := s.repo.IsTeamAdmin(actorID, req.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if !admin {
+			return nil, ErrInvitationForbidden
+		}
+	} else if err := s.checkAssignableRole(actorRoleID, roleID); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.EmailExists(email)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if exists {
+		return nil, errors.New("email já cadastrado")
+	}
+
+	inv := Invitation{
+		ID:        utils.GenerateSnowflakeID(),
+		Email:     email,
+		RoleID:    roleID,
+		InvitedBy: actorID,
+		Status:    InvitationPending,
+		ExpiresAt: time.Now().Add(s.signup.InvitationExpiry),
+		CreatedAt: time.Now(),
+	}
+	if req.TeamID != "" {
+		found, err := s.repo.TeamExists(req.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, errors.New("time não encontrado")
+		}
+
+		teamRole := req.TeamRole
+		if teamRole == "" {
+			teamRole = "Member"
+		}
+		inv.TeamID = &req.TeamID
+		inv.TeamRole = &teamRole
+	}
+
+	code, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar código do convite: %w", err)
+	}
+
+	// Reenviar substitui o convite anterior; o de outra pessoa, só quem
+	// poderia revogá-lo (users:invite).
+	if err := s.repo.CreateInvitation(inv, utils.HashToken(code), canInvite); err != nil {
+		return nil, err
+	}
+
+	link := fmt.Sprintf("%s/accept-invite?code=%s", strings.TrimRight(s.signup.AppURL, "/"), code)
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Você foi convidado",
+		Body: fmt.Sprintf(
+			"Olá.\n\nVocê recebeu um convite para criar sua conta. Use o link abaixo (válido por %d dias):\n\n%s\n\nSe você não esperava este convite, ignore este email.",
+			int(s.signup.InvitationExpiry.Hours()/24), link,
+		),
+	}
+	if err := s.signup.Mailer.Send(msg); err != nil {
+		log.Printf("❌ Erro ao enviar convite para %s: %v", email, err)
+		return nil, fmt.Errorf("erro ao enviar email: %w", err)
+	}
+
+	s.logAudit(actorID, "", audit.ActionInvitationCreated, ipAddress, map[string]any{
+		"invitation_id": inv.ID,
+		"email":         email,
+		"role_id":       roleID,
+		"team_id":       req.TeamID,
+	})
+
+	return &inv, nil
+}
+
+// ListInvitations: com users:invite, todos; sem, só os que o usuário enviou.
+func (s *Service) ListInvitations(actorID string, canInvite bool, status string) ([]Invitation, error) {
+	switch status {
+	case "", InvitationPending, InvitationAccepted, InvitationRevoked, InvitationExpired:
+	default:
+		return nil, errors.New("status deve ser pending, accepted, revoked ou expired")
+	}
+
+	invitedBy := actorID
+	if canInvite {
+		invitedBy = ""
+	}
+	return s.repo.ListInvitations(invitedBy, status)
+}
+
+// RevokeInvitation revoga um convite pendente (quem enviou ou users:invite).
+func (s *Service) RevokeInvitation(actorID string, canInvite bool, invitationID, ipAddress string) error {
+	inv, err := s.repo.FindInvitation(invitationID)
+	if err != nil {
+		return err
+	}
+	if inv == nil || (!canInvite && inv.InvitedBy != actorID) {
+		return errors.New("convite não encontrado")
+	}
+
+	revoked, err := s.repo.RevokeInvitation(invitationID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("só convites pendentes podem ser revogados")
+	}
+
+	s.logAudit(actorID, "", audit.ActionInvitationRevoked, ipAddress, map[string]any{
+		"invitation_id": invitationID,
+		"email":         inv.Email,
+	})
+	return nil
+}
+
+// AcceptInvitation cria a conta a partir do código. O email já chega
+// verificado: o código só foi entregue na caixa de entrada dele.
+func (s *Service) AcceptInvitation(req AcceptInvitationRequest, ipAddress string) (*models.User, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("erro de validação: %w", err)
+	}
+
+	inv, err := s.repo.FindInvitationByCode(utils.HashToken(req.Code))
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil || inv.Status != InvitationPending {
+		return nil, ErrInvitationInvalid
+	}
+
+	exists, err := s.repo.EmailExists(inv.Email)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if exists {
+		return nil, errors.New("email já cadastrado")
+	}
+
+	if err := s.policy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	user := models.User{
+		ID:              utils.GenerateSnowflakeID(),
+		Name:            strings.TrimSpace(req.Name),
+		Email:           inv.Email,
+		PasswordHash:    hash,
+		RoleID:          inv.RoleID,
+		IsActive:        true,
+		IsEmailVerified: true,
+	}
+	if err := s.repo.AcceptInvitation(inv, user); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(user.ID, user.ID, audit.ActionInvitationAccepted, ipAddress, map[string]any{
+		"invitation_id": inv.ID,
+		"invited_by":    inv.InvitedBy,
+		"team_id":       inv.TeamID,
+	})
+
+	created, err := s.repo.FindByID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário criado: %w", err)
+	}
+	created.PasswordHash = ""
+	return created, nil
+}
@@ -1,12 +1,27 @@
 package users
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"loginbackend/features/shared/models"
+)
 
 type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=3,max=100"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
-	RoleID   int    `json:"role_id,omitempty"`
+	Password string `json:"password" validate:"required"`
+
+	// RoleID nunca vem do JSON: o cadastro é público e sempre cria USER.
+	// Só é definido internamente; mudar o papel é PUT /users/{id}/role.
+	RoleID int `json:"-"`
+
+	// EmailVerified só é definido internamente (ex: login social com
+	// email já confirmado pelo provedor); nunca vem do JSON.
+	EmailVerified bool `json:"-"`
+	// GeneratedPassword marca senhas aleatórias criadas pelo sistema (ex:
+	// login social), que ninguém digita e por isso dispensam a política.
+	GeneratedPassword bool `json:"-"`
 }
 
 type UpdateUserRequest struct {
@@ -23,24 +38,101 @@ type UpdateUserRequest struct {
 	PostalCode *string `json:"postal_code,omitempty"`
 	TaxID      *string `json:"tax_id,omitempty"`
 
-	// Admin fields
-	RoleID *int `json:"role_id,omitempty"`
+	// Campos administrativos: só papéis com users:manage alteram is_active
+	// e is_email_verified. role_id não é aceito aqui (nem para admins):
+	// a troca de papel é auditada em PUT /users/{id}/role.
+	RoleID          *int  `json:"role_id,omitempty"`
+	IsActive        *bool `json:"is_active,omitempty"`
+	IsEmailVerified *bool `json:"is_email_verified,omitempty"`
+}
+
+// ChangeRoleRequest é o corpo de PUT /users/{id}/role.
+type ChangeRoleRequest struct {
+	RoleID int `json:"role_id" validate:"required,min=1"`
 }
 
 // Request específica para troca de senha
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" validate:"required"`
-	NewPassword     string `json:"new_password" validate:"required,min=6"`
+	NewPassword     string `json:"new_password" validate:"required"`
 }
 
 type UserResponse struct {
-	ID          string     `json:"id"`
-	Email       string     `json:"email"`
-	Name        string     `json:"name"`
-	RoleID      int        `json:"role_id"`
-	IsActive    bool       `json:"is_active"`
-	CreatedAt   time.Time  `json:"created_at"`
-	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	Name            string     `json:"name"`
+	RoleID          int        `json:"role_id"`
+	IsActive        bool       `json:"is_active"`
+	IsEmailVerified bool       `json:"is_email_verified"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
+}
+
+// ============================================
+// ADMINISTRAÇÃO (console)
+// ============================================
+
+// Ordenações aceitas em GET /users (sort=...).
+const (
+	SortCreatedAt   = "created_at"
+	SortName        = "name"
+	SortEmail       = "email"
+	SortLastLoginAt = "last_login_at"
+)
+
+// ListUsersFilter são os filtros de GET /users. Ponteiros nil = sem filtro.
+type ListUsersFilter struct {
+	Query           string
+	RoleID          *int
+	IsActive        *bool
+	IsEmailVerified *bool
+	CreatedFrom     *time.Time
+	CreatedTo       *time.Time
+	LastLoginFrom   *time.Time
+	LastLoginTo     *time.Time
+
+	Sort   string // created_at (padrão), name, email, last_login_at
+	Desc   bool
+	Limit  int
+	Cursor string
+
+	after *listCursor
+}
+
+// listCursor é a posição da última linha da página: valor da coluna de
+// ordenação + id (desempate). Vai para o cliente em base64url, junto com
+// a ordenação para a qual foi emitido (só vale para ela).
+type listCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v,omitempty"`
+	ID    string `json:"id"`
+}
+
+// UserPage é uma página de GET /users. NextCursor vazio = última página;
+// o total (com os filtros) vai no header X-Total-Count.
+type UserPage struct {
+	Users      []UserResponse `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// Ações em lote (POST /users/bulk).
+const (
+	BulkActivate   = "activate"
+	BulkDeactivate = "deactivate"
+	BulkChangeRole = "change_role"
+)
+
+type BulkUserActionRequest struct {
+	Action  string   `json:"action" validate:"required,oneof=activate deactivate change_role"`
+	UserIDs []string `json:"user_ids" validate:"required,min=1,max=500,dive,required,numeric"`
+	// RoleID só é usado (e obrigatório) em change_role.
+	RoleID int `json:"role_id,omitempty"`
+}
+
+type BulkUserActionResult struct {
+	Action   string `json:"action"`
+	Affected int    `json:"affected"`
 }
 
 // UserSearchResult é a versão pública/segura do usuário, usada em
@@ -51,3 +143,157 @@ type UserSearchResult struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
+
+// ============================================
+// IMPORTAÇÃO / EXPORTAÇÃO (CSV)
+// ============================================
+
+// ImportUserRow é uma linha da importação (CSV ou JSON). Linhas sem
+// senha recebem um link por email para o usuário definir a própria.
+type ImportUserRow struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+	RoleID   int    `json:"role_id,omitempty"`
+}
+
+type ImportUsersRequest struct {
+	Users []ImportUserRow `json:"users"`
+}
+
+// ImportRowError lista os problemas de uma linha (Row começa em 1, sem
+// contar o cabeçalho do CSV).
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Email  string   `json:"email"`
+	Errors []string `json:"errors"`
+}
+
+// ImportReport é o resultado da importação. Se houver erro em qualquer
+// linha, nada é criado; em dry_run os totais são os que seriam criados.
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Invited int              `json:"invited"`
+	Errors  []ImportRowError `json:"errors,omitempty"`
+}
+
+// ============================================
+// CONVITES E MODO DE CADASTRO
+// ============================================
+
+// Modos de cadastro público (SIGNUP_MODE).
+const (
+	SignupOpen   = "open"
+	SignupInvite = "invite"
+	SignupDomain = "domain"
+)
+
+// Situação do convite. InvitationExpired não é gravado: é um convite
+// pendente cujo prazo já passou.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+type Invitation struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	RoleID         int        `json:"role_id"`
+	TeamID         *string    `json:"team_id,omitempty"`
+	TeamRole       *string    `json:"team_role,omitempty"`
+	InvitedBy      string     `json:"invited_by"`
+	Status         string     `json:"status"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	AcceptedUserID *string    `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateInvitationRequest: sem role_id, a conta nasce USER. Com team_id,
+// o usuário entra no time ao aceitar (team_role padrão: Member).
+type CreateInvitationRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	RoleID   int    `json:"role_id,omitempty" validate:"omitempty,min=1"`
+	TeamID   string `json:"team_id,omitempty" validate:"omitempty,numeric"`
+	TeamRole string `json:"team_role,omitempty" validate:"omitempty,oneof=Admin Member Viewer"`
+}
+
+// AcceptInvitationRequest cria a conta a partir do código recebido por
+// email. O email é o do convite.
+type AcceptInvitationRequest struct {
+	Code     string `json:"code" validate:"required"`
+	Name     string `json:"name" validate:"required,min=3,max=100"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ============================================
+// PERSONAL ACCESS TOKENS
+// ============================================
+
+type PersonalAccessToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type CreatePersonalTokenRequest struct {
+	Name   string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=read write"`
+	// ExpiresInDays: padrão 30, máximo 365. Não existe token sem validade.
+	ExpiresInDays int `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+// PersonalTokenResponse é a visão da listagem. Token só vem preenchido
+// na criação — o banco guarda apenas o hash.
+type PersonalTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Token      string     `json:"token,omitempty"`
+}
+
+// personalTokenOwner é o que a validação precisa saber do dono do token.
+type personalTokenOwner struct {
+	Email        string
+	RoleID       int
+	IsActive     bool
+	TokenVersion int
+}
+
+// ============================================
+// PRIVACIDADE (LGPD/GDPR)
+// ============================================
+
+// AccountExport é o arquivo de portabilidade dos dados do usuário.
+type AccountExport struct {
+	ExportedAt   time.Time       `json:"exported_at"`
+	Profile      *models.User    `json:"profile"`
+	Tasks        json.RawMessage `json:"tasks" swaggertype:"array,object"`
+	TaskEvents   json.RawMessage `json:"task_events" swaggertype:"array,object"`
+	ACLsGranted  json.RawMessage `json:"acls_granted" swaggertype:"array,object"`
+	ACLsReceived json.RawMessage `json:"acls_received" swaggertype:"array,object"`
+}
+
+// ScheduleDeletionRequest: a senha é exigida quando o próprio usuário pede.
+type ScheduleDeletionRequest struct {
+	Password string `json:"password"`
+}
+
+type DeletionStatus struct {
+	ScheduledFor *time.Time `json:"scheduled_for"`
+}
@@ -0,0 +1,160 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ExportUser
+// @Summary Exportar dados do usuário (LGPD/GDPR)
+// @Description Perfil, tasks próprias, eventos de task criados e ACLs concedidas/recebidas. format=zip devolve um arquivo por seção.
+// @Tags users
+// @Produce json
+// @Produce application/zip
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param format query string false "json (padrão) ou zip"
+// @Success 200 {object} Response{data=AccountExport}
+// @Failure 403 {object} Response
+// @Router /users/{id}/export [get]
+func (h *Handler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := chi.URLParam(r, "id")
+
+	if r.URL.Query().Get("format") == "zip" {
+		archive, err := h.service.ExportZip(claims.UserID, claims.RoleID, userID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(privacyErrorStatus(err, http.StatusInternalServerError))
+			json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.zip"`, userID))
+		w.Write(archive)
+		return
+	}
+
+	export, err := h.service.Export(claims.UserID, claims.RoleID, userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(privacyErrorStatus(err, http.StatusInternalServerError))
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.json"`, userID))
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: export})
+}
+
+// ScheduleDeletion
+// @Summary Agendar exclusão da conta
+// @Description Agenda a anonimização da conta após o período de carência (30 dias), cancelável até lá. O próprio usuário confirma com a senha ou, sem senha (ex: contas de login social), com um login feito há menos de 10 minutos. Para outra conta é preciso ter as permissões do papel dela.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body ScheduleDeletionRequest false "Senha atual (ou login recente) do próprio usuário"
+// @Success 200 {object} Response{data=DeletionStatus}
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Router /users/{id}/deletion [post]
+func (h *Handler) ScheduleDeletion(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := chi.URLParam(r, "id")
+
+	var req ScheduleDeletionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "dados inválidos", http.StatusBadRequest)
+			return
+		}
+	}
+
+	status, err := h.service.ScheduleDeletion(claims.UserID, claims.RoleID, claims.SessionID, userID, req.Password, middleware.RemoteIP(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(privacyErrorStatus(err, http.StatusBadRequest))
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "exclusão agendada", Data: status})
+}
+
+// GetDeletionStatus
+// @Summary Situação da exclusão da conta
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} Response{data=DeletionStatus}
+// @Router /users/{id}/deletion [get]
+func (h *Handler) GetDeletionStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.DeletionStatus(chi.URLParam(r, "id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: status})
+}
+
+// CancelDeletion
+// @Summary Cancelar exclusão da conta
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Router /users/{id}/deletion [delete]
+func (h *Handler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.CancelDeletion(claims.UserID, claims.RoleID, chi.URLParam(r, "id"), middleware.RemoteIP(r)); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(privacyErrorStatus(err, http.StatusBadRequest))
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "exclusão cancelada"})
+}
+
+// privacyErrorStatus: falta de permissão sobre o papel do alvo vira 403;
+// o resto, fallback.
+func privacyErrorStatus(err error, fallback int) int {
+	if errors.Is(err, ErrRoleNotGrantable) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
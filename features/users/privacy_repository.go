@@ -0,0 +1,182 @@
+package users
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// exportQueries monta cada seção do arquivo de exportação direto em JSON
+// no Postgres (json_agg), sem precisar de um struct por tabela.
+var exportQueries = map[string]string{
+	"tasks": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]')
+		FROM (
+			SELECT id::text, title, description, due_date, priority, status, team_id::text,
+			       location_lat, location_lng, farm_area_id::text, version,
+			       created_at, updated_at, deleted_at
+			FROM tasks WHERE owner_id = $1
+		) t`,
+	"task_events": `
+		SELECT COALESCE(json_agg(e ORDER BY e.server_timestamp), '[]')
+		FROM (
+			SELECT id, task_id::text, event_type, payload, version,
+			       client_timestamp, server_timestamp
+			FROM task_events WHERE user_id = $1
+		) e`,
+	"acls_granted": `
+		SELECT COALESCE(json_agg(a ORDER BY a.granted_at), '[]')
+		FROM (
+			SELECT id, resource_id::text, resource_type, grantee_type, grantee_id::text,
+			       permissions, granted_at, expires_at, metadata
+			FROM acls WHERE granted_by = $1
+		) a`,
+	"acls_received": `
+		SELECT COALESCE(json_agg(a ORDER BY a.granted_at), '[]')
+		FROM (
+			SELECT id, resource_id::text, resource_type, permissions, granted_by::text,
+			       granted_at, expires_at, metadata
+			FROM acls WHERE grantee_type = 'USER' AND grantee_id = $1
+		) a`,
+}
+
+// ExportSection devolve uma seção do export (tasks, task_events, ...) em JSON.
+func (r *Repository) ExportSection(userID, section string) (json.RawMessage, error) {
+	query, ok := exportQueries[section]
+	if !ok {
+		return nil, fmt.Errorf("seção de exportação desconhecida: %s", section)
+	}
+
+	var data []byte
+	if err := r.db.QueryRow(query, userID).Scan(&data); err != nil {
+		return nil, fmt.Errorf("erro ao exportar %s: %w", section, err)
+	}
+	return json.RawMessage(data), nil
+}
+
+// DeletionScheduledFor devolve a data agendada da exclusão (nil se não houver).
+func (r *Repository) DeletionScheduledFor(userID string) (*time.Time, error) {
+	var scheduledFor sql.NullTime
+	err := r.db.QueryRow(
+		`SELECT deletion_scheduled_for FROM users WHERE id = $1 AND anonymized_at IS NULL`,
+		userID,
+	).Scan(&scheduledFor)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar exclusão agendada: %w", err)
+	}
+	if !scheduledFor.Valid {
+		return nil, nil
+	}
+	return &scheduledFor.Time, nil
+}
+
+func (r *Repository) ScheduleDeletion(userID string, scheduledFor time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE users
+		SET deletion_requested_at = CURRENT_TIMESTAMP, deletion_scheduled_for = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND anonymized_at IS NULL
+	`, userID, scheduledFor)
+	if err != nil {
+		return fmt.Errorf("erro ao agendar exclusão: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) CancelDeletion(userID string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE users
+		SET deletion_requested_at = NULL, deletion_scheduled_for = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deletion_scheduled_for IS NOT NULL AND anonymized_at IS NULL
+	`, userID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao cancelar exclusão: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DueDeletions lista as contas cujo período de carência já acabou.
+func (r *Repository) DueDeletions(limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT id FROM users
+		WHERE deletion_scheduled_for <= CURRENT_TIMESTAMP AND anonymized_at IS NULL
+		ORDER BY deletion_scheduled_for
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar exclusões pendentes: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// Anonymize apaga os dados pessoais da conta numa transação. A linha em
+// users fica (com email e nome fictícios) para não quebrar as FKs de
+// tasks, task_events, acls e audit_logs; credenciais, sessões, vínculos
+// e acessos recebidos são removidos.
+func (r *Repository) Anonymize(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE users SET
+			email = 'deleted-' || id || '@deleted.invalid',
+			name = 'Usuário removido',
+			password_hash = '',
+			refresh_token = NULL,
+			totp_secret = NULL, totp_enabled = false,
+			profile_image_url = NULL, avatar_url = NULL, avatar_variants = NULL,
+			phone = NULL, job_title = NULL, location = NULL,
+			country = NULL, city = NULL, state = NULL, postal_code = NULL, tax_id = NULL,
+			is_active = false, is_email_verified = false, locked_until = NULL,
+			deletion_scheduled_for = NULL,
+			anonymized_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND anonymized_at IS NULL
+	`, userID); err != nil {
+		return fmt.Errorf("erro ao anonimizar usuário: %w", err)
+	}
+
+	cleanup := []string{
+		`DELETE FROM user_sessions WHERE user_id = $1`,
+		`DELETE FROM user_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM webauthn_credentials WHERE user_id = $1`,
+		`DELETE FROM personal_access_tokens WHERE user_id = $1`,
+		`DELETE FROM password_history WHERE user_id = $1`,
+		`DELETE FROM oauth_consents WHERE user_id = $1`,
+		`DELETE FROM acls WHERE grantee_type = 'USER' AND grantee_id = $1`,
+		`DELETE FROM resource_permissions_cache WHERE user_id = $1`,
+		`DELETE FROM task_collaborators WHERE user_id = $1`,
+		`DELETE FROM team_members WHERE user_id = $1`,
+		`UPDATE invitations SET email = 'deleted-' || $1 || '@deleted.invalid' WHERE accepted_user_id = $1`,
+	}
+	for _, query := range cleanup {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return fmt.Errorf("erro ao remover dados do usuário: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,267 @@
+package users
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"loginbackend/features/shared/models"
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/utils"
+)
+
+// accountDeletionGracePeriod é o prazo entre o pedido de exclusão e a
+// anonimização; até lá o pedido pode ser cancelado.
+const accountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// purgeBatchSize limita quantas contas cada rodada de PurgeDueDeletions anonimiza.
+const purgeBatchSize = 100
+
+// recentLoginWindow: sem senha, o próprio usuário confirma a exclusão com
+// um login feito há pouco — contas de login social só têm uma senha
+// aleatória que ninguém conhece.
+const recentLoginWindow = 10 * time.Minute
+
+// ErrDeletionNotConfirmed: o próprio usuário não confirmou a exclusão.
+var ErrDeletionNotConfirmed = errors.New("confirme a exclusão com a senha atual ou faça login novamente")
+
+var exportSections = []string{"tasks", "task_events", "acls_granted", "acls_received"}
+
+// AvatarRemover apaga o arquivo de avatar do usuário no storage.
+type AvatarRemover func(userID string) error
+
+// SetAvatarRemover injeta a remoção do avatar usada na anonimização
+// (depende da configuração de upload, que o service não conhece).
+func (s *Service) SetAvatarRemover(remover AvatarRemover) {
+	s.removeAvatar = remover
+}
+
+// Export reúne os dados do usuário para portabilidade (LGPD/GDPR).
+// Exportar outra pessoa exige as permissões do papel dela.
+func (s *Service) Export(actorID string, actorRoleID int, userID string) (*AccountExport, error) {
+	if err := s.checkCanActOn(actorID, actorRoleID, userID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &AccountExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    user,
+	}
+
+	sections := make(map[string]json.RawMessage, len(exportSections))
+	for _, section := range exportSections {
+		data, err := s.repo.ExportSection(userID, section)
+		if err != nil {
+			return nil, err
+		}
+		sections[section] = data
+	}
+	export.Tasks = sections["tasks"]
+	export.TaskEvents = sections["task_events"]
+	export.ACLsGranted = sections["acls_granted"]
+	export.ACLsReceived = sections["acls_received"]
+
+	return export, nil
+}
+
+// ExportZip é o mesmo conteúdo de Export, um arquivo JSON por seção.
+func (s *Service) ExportZip(actorID string, actorRoleID int, userID string) ([]byte, error) {
+	export, err := s.Export(actorID, actorRoleID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"tasks.json", export.Tasks},
+		{"task_events.json", export.TaskEvents},
+		{"acls_granted.json", export.ACLsGranted},
+		{"acls_received.json", export.ACLsReceived},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao montar arquivo de exportação: %w", err)
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, fmt.Errorf("erro ao montar arquivo de exportação: %w", err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("erro ao montar arquivo de exportação: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ScheduleDeletion agenda a exclusão da conta para daqui a
+// accountDeletionGracePeriod. O próprio usuário confirma com a senha ou
+// com um login recente (sessionID é a sessão do token de quem pede); um
+// administrador precisa ter as permissões do papel do usuário.
+func (s *Service) ScheduleDeletion(actorID string, actorRoleID int, sessionID, userID, password, ipAddress string) (*DeletionStatus, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	if actorID == userID {
+		if err := s.confirmDeletion(user, sessionID, password); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkRolesGrantable(actorRoleID, user.RoleID); err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.DeletionScheduledFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return &DeletionStatus{ScheduledFor: current}, nil
+	}
+
+	scheduledFor := time.Now().Add(accountDeletionGracePeriod)
+	if err := s.repo.ScheduleDeletion(userID, scheduledFor); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(actorID, userID, audit.ActionAccountDeletionScheduled, ipAddress, map[string]any{
+		"scheduled_for": scheduledFor,
+	})
+
+	return &DeletionStatus{ScheduledFor: &scheduledFor}, nil
+}
+
+// confirmDeletion aceita a senha atual ou, sem senha, uma sessão aberta
+// há menos de recentLoginWindow.
+func (s *Service) confirmDeletion(user *models.User, sessionID, password string) error {
+	if password != "" {
+		if !utils.CheckPassword(password, user.PasswordHash) {
+			return errors.New("senha incorreta")
+		}
+		return nil
+	}
+
+	startedAt, err := s.sessions.SessionStartedAt(user.ID, sessionID)
+	if err != nil {
+		return err
+	}
+	if startedAt == nil || time.Since(*startedAt) > recentLoginWindow {
+		return ErrDeletionNotConfirmed
+	}
+	return nil
+}
+
+func (s *Service) CancelDeletion(actorID string, actorRoleID int, userID, ipAddress string) error {
+	if err := s.checkCanActOn(actorID, actorRoleID, userID); err != nil {
+		return err
+	}
+
+	cancelled, err := s.repo.CancelDeletion(userID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.New("não há exclusão agendada para esta conta")
+	}
+
+	s.logAudit(actorID, userID, audit.ActionAccountDeletionCancelled, ipAddress, nil)
+	return nil
+}
+
+func (s *Service) DeletionStatus(userID string) (*DeletionStatus, error) {
+	scheduledFor, err := s.repo.DeletionScheduledFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &DeletionStatus{ScheduledFor: scheduledFor}, nil
+}
+
+// PurgeDueDeletions anonimiza as contas com período de carência vencido.
+// Roda periodicamente (ver main); uma falha numa conta não impede as outras.
+func (s *Service) PurgeDueDeletions() (int, error) {
+	ids, err := s.repo.DueDeletions(purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range ids {
+		if err := s.anonymize(userID); err != nil {
+			log.Printf("❌ Erro ao anonimizar usuário %s: %v", userID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// anonymize derruba sessões e tokens antes de apagar os dados: um access
+// token ainda vivo não pode continuar agindo pela conta.
+func (s *Service) anonymize(userID string) error {
+	if err := s.sessions.RevokeAllSessions(userID, "account_deleted"); err != nil {
+		return err
+	}
+
+	if err := s.repo.Anonymize(userID); err != nil {
+		return err
+	}
+
+	if s.removeAvatar != nil {
+		if err := s.removeAvatar(userID); err != nil {
+			log.Printf("⚠️ Erro ao remover avatar de %s: %v", userID, err)
+		}
+	}
+
+	s.logAudit("", userID, audit.ActionAccountAnonymized, "", nil)
+	return nil
+}
+
+// logAudit é best-effort: a operação já foi gravada. actorID vazio
+// significa o próprio sistema (ex: anonimização agendada); userID vazio,
+// uma ação sem conta alvo (ex: convite ainda não aceito).
+func (s *Service) logAudit(actorID, userID, action, ipAddress string, metadata map[string]any) {
+	entry := audit.Entry{
+		Action:    action,
+		IPAddress: ipAddress,
+		Metadata:  metadata,
+	}
+	if actorID != "" {
+		entry.ActorID = &actorID
+	}
+	if userID != "" {
+		entry.TargetUserID = &userID
+	}
+
+	if err := s.audit.Log(entry); err != nil {
+		log.Printf("⚠️ Erro ao auditar %s de %s: %v", action, userID, err)
+	}
+}
@@ -2,6 +2,7 @@ package users
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"loginbackend/features/shared/models"
 	"loginbackend/pkg/utils"
@@ -18,8 +19,8 @@ func NewRepository(db *sql.DB) *Repository {
 func (r *Repository) Create(user models.User) error {
 	query := `
 		INSERT INTO users 
-		(id, email, name, password_hash, role_id, is_active) 
-		VALUES ($1, $2, $3, $4, $5, $6)
+		(id, email, name, password_hash, role_id, is_active, is_email_verified) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 	_, err := r.db.Exec(query,
 		user.ID,
@@ -28,6 +29,7 @@ func (r *Repository) Create(user models.User) error {
 		user.PasswordHash,
 		user.RoleID,
 		user.IsActive,
+		user.IsEmailVerified,
 	)
 	if err != nil {
 		return fmt.Errorf("erro ao criar usuário no banco de dados: %w", err)
@@ -43,7 +45,7 @@ func (r *Repository) FindByID(id string) (*models.User, error) {
 			   created_at, updated_at, last_password_update, is_email_verified,
 			   last_login_at, profile_image_url, refresh_token,
 			   phone, job_title, location, avatar_url,
-			   country, city, state, postal_code, tax_id
+			   country, city, state, postal_code, tax_id, avatar_variants
 		FROM users WHERE id = $1
 	`, id)
 
@@ -57,7 +59,7 @@ func (r *Repository) FindByEmail(email string) (*models.User, error) {
 			   created_at, updated_at, last_password_update, is_email_verified,
 			   last_login_at, profile_image_url, refresh_token,
 			   phone, job_title, location, avatar_url,
-			   country, city, state, postal_code, tax_id
+			   country, city, state, postal_code, tax_id, avatar_variants
 		FROM users WHERE email = $1
 	`, email)
 
@@ -75,49 +77,21 @@ func (r *Repository) EmailExists(email string) (bool, error) {
 	return count > 0, nil
 }
 
-func (r *Repository) List() ([]models.User, error) {
-	rows, err := r.db.Query(`
-		SELECT id, email, name, password_hash, role_id, is_active, 
-			   created_at, updated_at, last_password_update, is_email_verified,
-			   last_login_at, profile_image_url, refresh_token,
-			   phone, job_title, location, avatar_url,
-			   country, city, state, postal_code, tax_id
-		FROM users ORDER BY created_at DESC
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao executar query de listagem: %w", err)
-	}
-	defer rows.Close()
-
-	var users []models.User
-	for rows.Next() {
-		user, err := r.scanUserFromRows(rows)
-		if err != nil {
-			return nil, fmt.Errorf("erro ao scanear usuário: %w", err)
-		}
-		users = append(users, *user)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("erro durante iteração dos resultados: %w", err)
-	}
-
-	return users, nil
-}
-
 func (r *Repository) Update(user models.User) error {
 	query := `
 		UPDATE users SET 
 			email = $1, name = $2, role_id = $3, is_active = $4,
 			phone = $5, job_title = $6, location = $7,
 			country = $8, city = $9, state = $10, postal_code = $11, tax_id = $12,
+			is_email_verified = $13,
 			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $13
+		WHERE id = $14
 	`
 	result, err := r.db.Exec(query,
 		user.Email, user.Name, user.RoleID, user.IsActive,
 		user.Phone, user.JobTitle, user.Location,
 		user.Country, user.City, user.State, user.PostalCode, user.TaxID,
+		user.IsEmailVerified,
 		user.ID,
 	)
 	if err != nil {
@@ -136,6 +110,27 @@ func (r *Repository) Update(user models.User) error {
 	return nil
 }
 
+// UpdateRole grava o papel novo (a troca passa por Service.ChangeRole).
+func (r *Repository) UpdateRole(userID string, roleID int) error {
+	_, err := r.db.Exec(
+		`UPDATE users SET role_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		roleID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao alterar papel do usuário: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) RoleExists(roleID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM roles WHERE id = $1)`, roleID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar papel: %w", err)
+	}
+	return exists, nil
+}
+
 func (r *Repository) Delete(id string) error {
 	result, err := r.db.Exec(
 		`UPDATE users SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
@@ -252,6 +247,7 @@ func (r *Repository) scanUser(row *sql.Row) (*models.User, error) {
 	var lastLoginAt, refreshToken, profileImageUrl sql.NullString
 	var phone, jobTitle, location, avatarUrl, country, city, state, postalCode, taxId sql.NullString
 	var lastPasswordUpdate sql.NullTime
+	var avatarVariants []byte
 
 	err := row.Scan(
 		&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.RoleID, &user.IsActive,
@@ -259,7 +255,7 @@ func (r *Repository) scanUser(row *sql.Row) (*models.User, error) {
 		&lastLoginAt, &profileImageUrl, &refreshToken,
 		// Novos campos
 		&phone, &jobTitle, &location, &avatarUrl,
-		&country, &city, &state, &postalCode, &taxId,
+		&country, &city, &state, &postalCode, &taxId, &avatarVariants,
 	)
 
 	if err != nil {
@@ -269,7 +265,7 @@ func (r *Repository) scanUser(row *sql.Row) (*models.User, error) {
 		return nil, fmt.Errorf("erro ao scanear usuário: %w", err)
 	}
 
-	return r.mapScannedValues(&user, lastPasswordUpdate, profileImageUrl, refreshToken, lastLoginAt, phone, jobTitle, location, avatarUrl, country, city, state, postalCode, taxId)
+	return r.mapScannedValues(&user, lastPasswordUpdate, profileImageUrl, refreshToken, lastLoginAt, phone, jobTitle, location, avatarUrl, country, city, state, postalCode, taxId, avatarVariants)
 }
 
 // scanUserFromRows - Helper para scan do sql.Rows
@@ -278,6 +274,7 @@ func (r *Repository) scanUserFromRows(rows *sql.Rows) (*models.User, error) {
 	var lastLoginAt, refreshToken, profileImageUrl sql.NullString
 	var phone, jobTitle, location, avatarUrl, country, city, state, postalCode, taxId sql.NullString
 	var lastPasswordUpdate sql.NullTime
+	var avatarVariants []byte
 
 	err := rows.Scan(
 		&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.RoleID, &user.IsActive,
@@ -285,14 +282,14 @@ func (r *Repository) scanUserFromRows(rows *sql.Rows) (*models.User, error) {
 		&lastLoginAt, &profileImageUrl, &refreshToken,
 		// Novos campos
 		&phone, &jobTitle, &location, &avatarUrl,
-		&country, &city, &state, &postalCode, &taxId,
+		&country, &city, &state, &postalCode, &taxId, &avatarVariants,
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("erro ao scanear usuário do rows: %w", err)
 	}
 
-	return r.mapScannedValues(&user, lastPasswordUpdate, profileImageUrl, refreshToken, lastLoginAt, phone, jobTitle, location, avatarUrl, country, city, state, postalCode, taxId)
+	return r.mapScannedValues(&user, lastPasswordUpdate, profileImageUrl, refreshToken, lastLoginAt, phone, jobTitle, location, avatarUrl, country, city, state, postalCode, taxId, avatarVariants)
 }
 
 // mapScannedValues - Helper privado para evitar duplicação de código de mapeamento
@@ -301,6 +298,7 @@ func (r *Repository) mapScannedValues(
 	lastPasswordUpdate sql.NullTime,
 	profileImageUrl, refreshToken, lastLoginAt sql.NullString,
 	phone, jobTitle, location, avatarUrl, country, city, state, postalCode, taxId sql.NullString,
+	avatarVariants []byte,
 ) (*models.User, error) {
 
 	if lastPasswordUpdate.Valid {
@@ -336,6 +334,11 @@ func (r *Repository) mapScannedValues(
 	if avatarUrl.Valid {
 		user.AvatarURL = &avatarUrl.String
 	}
+	if len(avatarVariants) > 0 {
+		if err := json.Unmarshal(avatarVariants, &user.AvatarVariants); err != nil {
+			return nil, fmt.Errorf("erro ao ler variantes do avatar: %w", err)
+		}
+	}
 	if country.Valid {
 		user.Country = &country.String
 	}
@@ -355,18 +358,67 @@ func (r *Repository) mapScannedValues(
 	return user, nil
 }
 
+// UpdatePassword troca o hash e guarda o anterior no histórico.
 func (r *Repository) UpdatePassword(userID, newHash string) error {
-	_, err := r.db.Exec(`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO password_history (user_id, password_hash)
+		SELECT id, password_hash FROM users WHERE id = $1
+	`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
 		UPDATE users 
 		SET password_hash = $1, last_password_update = CURRENT_TIMESTAMP 
 		WHERE id = $2
-	`, newHash, userID)
-	return err
+	`, newHash, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PasswordHistory devolve os hashes anteriores, do mais recente ao mais antigo.
+func (r *Repository) PasswordHistory(userID string, limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT password_hash FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
 }
 
-func (r *Repository) UpdateAvatar(userID, avatarURL string) error {
-	query := `UPDATE users SET avatar_url = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(query, avatarURL, userID)
+// UpdateAvatar grava a URL padrão (avatar_url, mantida para clientes
+// antigos) e o mapa de variantes por tamanho.
+func (r *Repository) UpdateAvatar(userID, avatarURL string, variants map[string]string) error {
+	variantsJSON, err := json.Marshal(variants)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE users SET avatar_url = $1, avatar_variants = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	_, err = r.db.Exec(query, avatarURL, variantsJSON, userID)
 	return err
 }
 
@@ -2,42 +2,86 @@ package users
 
 import (
 	"loginbackend/internal/http/middleware"
+	"loginbackend/pkg/jwtkeys"
+	"loginbackend/pkg/rbac"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/redis/go-redis/v9"
 )
 
-func Routes(handler *Handler, jwtSecret string, redisClient *redis.Client) (string, func(r chi.Router)) {
+func Routes(handler *Handler, keys *jwtkeys.Manager, redisClient *redis.Client, tokens middleware.PersonalTokenValidator, permissions middleware.PermissionResolver) (string, func(r chi.Router)) {
 
 	return "/users", func(r chi.Router) {
-		// 1. Rota Pública (Registro)
+		// 1. Rotas Públicas (Registro — sujeito a SIGNUP_MODE — e aceite de convite)
 		r.Post("/", handler.CreateUser)
+		r.Post("/invitations/accept", handler.AcceptInvitation)
 
 		// 2. Rotas Protegidas (Requerem Login)
 		r.Group(func(r chi.Router) {
 			// Middleware de Autenticação
-			r.Use(middleware.AuthMiddleware(jwtSecret, redisClient))
+			r.Use(middleware.AuthMiddleware(keys, redisClient, tokens))
 
 			// Busca para compartilhamento — qualquer usuário autenticado
 			r.Get("/search", handler.SearchUsers)
 
-			// A) LISTAGEM GERAL: Apenas Admins (opcional, ou aberto a todos dependendo da regra)
-			// Vamos manter restrito a Admin para evitar vazamento de base
-			r.With(middleware.RequireRole(1)).Get("/", handler.ListUsers)
+			// A) LISTAGEM GERAL: só papéis com users:list
+			// (restrito para evitar vazamento de base)
+			r.With(middleware.RequireSystemPermission(permissions, rbac.UsersList)).Get("/", handler.ListUsers)
+
+			// Ações em lote do console (a permissão depende da ação: users:manage
+			// ou users:update_role — conferida no handler)
+			r.With(middleware.DenyImpersonation).Post("/bulk", handler.BulkUserAction)
+
+			// Convites: users:invite ou admin do time (conferido no serviço)
+			r.Get("/invitations", handler.ListInvitations)
+			r.With(middleware.DenyImpersonation).Post("/invitations", handler.CreateInvitation)
+			r.With(middleware.DenyImpersonation).Delete("/invitations/{invitationID}", handler.RevokeInvitation)
+
+			// Importação/exportação do diretório (CSV)
+			r.With(
+				middleware.RequireSystemPermission(permissions, rbac.UsersManage),
+				middleware.DenyImpersonation,
+			).Post("/import", handler.ImportUsers)
+			r.With(middleware.RequireSystemPermission(permissions, rbac.UsersList)).Get("/export", handler.ExportUsers)
+
+			// Troca de papel: endpoint próprio e auditado (nunca via PUT /{id})
+			r.With(
+				middleware.RequireSystemPermission(permissions, rbac.UsersUpdateRole),
+				middleware.DenyImpersonation,
+			).Put("/{id}/role", handler.ChangeRole)
 
 			// B) LEITURA DE PERFIL: Aberto para qualquer usuário logado ver o perfil de outros
 			// (Se quiser que seja privado, mova para o grupo de baixo)
 			r.Get("/{id}", handler.GetUser)
 
-			// C) ZONA DE PERIGO (Owner Policy): Apenas o Dono ou Admin toca aqui
+			// C) ZONA DE PERIGO (Owner Policy): Apenas o Dono ou quem tem users:manage.
+			// Tudo aqui é bloqueado durante personificação (suporte) — inclusive
+			// o PUT: trocar o email e pedir "esqueci a senha" tomaria a conta.
 			r.Group(func(r chi.Router) {
-				r.Use(middleware.OwnerOrAdmin)
+				r.Use(middleware.OwnerOrAdmin(permissions))
+				r.Use(middleware.DenyImpersonation)
 
 				r.Put("/{id}", handler.UpdateUser)
+				r.Post("/{id}/avatar", handler.UploadAvatar)
 				r.Delete("/{id}", handler.DeleteUser)
 
-				r.Post("/{id}/change-password", handler.ChangePassword)
-				r.Post("/{id}/avatar", handler.UploadAvatar)
+				// Credenciais: exigem login de verdade, não um personal access token
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.DenyPersonalToken)
+
+					r.Post("/{id}/change-password", handler.ChangePassword)
+
+					// Personal access tokens (integrações)
+					r.Get("/{id}/tokens", handler.ListPersonalTokens)
+					r.Post("/{id}/tokens", handler.CreatePersonalToken)
+					r.Delete("/{id}/tokens/{tokenID}", handler.RevokePersonalToken)
+				})
+
+				// Privacidade (LGPD/GDPR): exportação e exclusão da conta
+				r.Get("/{id}/export", handler.ExportUser)
+				r.Get("/{id}/deletion", handler.GetDeletionStatus)
+				r.Post("/{id}/deletion", handler.ScheduleDeletion)
+				r.Delete("/{id}/deletion", handler.CancelDeletion)
 			})
 		})
 	}
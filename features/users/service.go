@@ -3,30 +3,83 @@ package users
 import (
 	"errors"
 	"fmt"
+	"log"
 	"loginbackend/features/shared/models"
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/password"
+	"loginbackend/pkg/rbac"
 	"loginbackend/pkg/utils"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// EmailVerifier é o necessário de 'auth' para enviar o link de
+// verificação de email e o de definição de senha (contas importadas).
+// Interface mínima para não acoplar users a auth.
+type EmailVerifier interface {
+	SendVerification(userID, email, name string) error
+	SendAccountSetup(userID, email, name string) error
+}
+
+// SessionRevoker é o necessário de 'auth' para derrubar os tokens de
+// um usuário quando a conta muda de forma sensível — e para saber quando
+// a sessão atual foi aberta (login recente como confirmação).
+type SessionRevoker interface {
+	RevokeAllSessions(userID, reason string) error
+	InvalidateAccessTokens(userID string) error
+	SessionStartedAt(userID, sessionID string) (*time.Time, error)
+}
+
+// RolePermissionResolver é o necessário de 'roles' para comparar o
+// papel de quem altera com o papel atribuído (implementado por roles.Service).
+type RolePermissionResolver interface {
+	RolePermissions(roleID int) (rbac.Set, error)
+}
+
+// Erros de autorização por campo (viram 403 no handler).
+var (
+	ErrAdminOnlyField    = errors.New("is_active e is_email_verified só podem ser alterados por um administrador")
+	ErrRoleChangeNotHere = errors.New("role_id não pode ser alterado aqui. Use PUT /users/{id}/role")
+	ErrRoleNotGrantable  = errors.New("não é possível atribuir ou alterar um papel com permissões que você não tem")
+	ErrOwnRoleChange     = errors.New("não é possível alterar o próprio papel")
+)
+
 type Service struct {
 	repo     *Repository
 	validate *validator.Validate
+	verifier EmailVerifier
+	sessions SessionRevoker
+	policy   *password.Policy
+	roles    RolePermissionResolver
+	audit    *audit.Logger
+
+	removeAvatar AvatarRemover
+	signup       SignupConfig
 }
 
-func NewService(repo *Repository) *Service {
+func NewService(repo *Repository, verifier EmailVerifier, sessions SessionRevoker, policy *password.Policy, roles RolePermissionResolver, auditLogger *audit.Logger) *Service {
 	return &Service{
 		repo:     repo,
 		validate: validator.New(),
+		verifier: verifier,
+		sessions: sessions,
+		policy:   policy,
+		roles:    roles,
+		audit:    auditLogger,
 	}
 }
 
-// Create - Cria usuário
+// Create - Cria usuário (cadastro público e login social; respeita SIGNUP_MODE)
 func (s *Service) Create(req CreateUserRequest) (*models.User, error) {
 	if err := s.validate.Struct(req); err != nil {
 		return nil, fmt.Errorf("erro de validação: %w", err)
 	}
 
+	if err := s.checkSignup(req.Email); err != nil {
+		return nil, err
+	}
+
 	exists, err := s.repo.EmailExists(req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao verificar email: %w", err)
@@ -35,6 +88,12 @@ func (s *Service) Create(req CreateUserRequest) (*models.User, error) {
 		return nil, errors.New("email já cadastrado")
 	}
 
+	if !req.GeneratedPassword {
+		if err := s.policy.Validate(req.Password); err != nil {
+			return nil, err
+		}
+	}
+
 	hash, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao gerar hash da senha: %w", err)
@@ -48,12 +107,13 @@ func (s *Service) Create(req CreateUserRequest) (*models.User, error) {
 	snowflakeID := utils.GenerateSnowflakeID()
 
 	user := models.User{
-		ID:           snowflakeID,
-		Name:         req.Name,
-		Email:        req.Email,
-		PasswordHash: hash,
-		RoleID:       roleID,
-		IsActive:     true,
+		ID:              snowflakeID,
+		Name:            req.Name,
+		Email:           req.Email,
+		PasswordHash:    hash,
+		RoleID:          roleID,
+		IsActive:        true,
+		IsEmailVerified: req.EmailVerified,
 	}
 
 	if err := s.repo.Create(user); err != nil {
@@ -65,10 +125,22 @@ func (s *Service) Create(req CreateUserRequest) (*models.User, error) {
 		return nil, fmt.Errorf("erro ao buscar usuário criado: %w", err)
 	}
 
+	if !createdUser.IsEmailVerified {
+		s.sendVerification(createdUser)
+	}
+
 	createdUser.PasswordHash = ""
 	return createdUser, nil
 }
 
+// sendVerification é best-effort: falha no envio não desfaz o cadastro
+// nem a alteração — o usuário pode pedir reenvio depois.
+func (s *Service) sendVerification(user *models.User) {
+	if err := s.verifier.SendVerification(user.ID, user.Email, user.Name); err != nil {
+		log.Printf("⚠️ Erro ao enviar verificação de email para %s: %v", user.Email, err)
+	}
+}
+
 // GetByID - Busca usuário por ID
 func (s *Service) GetByID(userID string) (*models.User, error) {
 	user, err := s.repo.FindByID(userID)
@@ -83,24 +155,20 @@ func (s *Service) GetByID(userID string) (*models.User, error) {
 	return user, nil
 }
 
-// List - Lista todos os usuários
-func (s *Service) List() ([]models.User, error) {
-	users, err := s.repo.List()
-	if err != nil {
-		return nil, fmt.Errorf("erro ao listar usuários: %w", err)
+// Update - Atualiza usuário. canManage diz se quem chama tem users:manage:
+// OwnerOrAdmin libera a rota para o dono, mas os campos administrativos
+// do próprio cadastro continuam fora do alcance dele. Alterar outra pessoa
+// exige ter todas as permissões do papel dela (ver checkRolesGrantable).
+func (s *Service) Update(actorID string, actorRoleID int, userID string, req UpdateUserRequest, canManage bool) (*models.User, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("erro de validação: %w", err)
 	}
 
-	for i := range users {
-		users[i].PasswordHash = ""
+	if req.RoleID != nil {
+		return nil, ErrRoleChangeNotHere
 	}
-
-	return users, nil
-}
-
-// Update - Atualiza usuário
-func (s *Service) Update(userID string, req UpdateUserRequest) (*models.User, error) {
-	if err := s.validate.Struct(req); err != nil {
-		return nil, fmt.Errorf("erro de validação: %w", err)
+	if !canManage && (req.IsActive != nil || req.IsEmailVerified != nil) {
+		return nil, ErrAdminOnlyField
 	}
 
 	existing, err := s.repo.FindByID(userID)
@@ -111,11 +179,31 @@ func (s *Service) Update(userID string, req UpdateUserRequest) (*models.User, er
 		return nil, errors.New("usuário não encontrado")
 	}
 
+	// users:manage não vira atalho para desativar (ou tomar, trocando o
+	// email) a conta de um papel com mais permissões.
+	if actorID != userID {
+		if err := s.checkRolesGrantable(actorRoleID, existing.RoleID); err != nil {
+			return nil, err
+		}
+	}
+
 	if req.Name != nil {
 		existing.Name = *req.Name
 	}
-	if req.Email != nil {
+	emailChanged := false
+	if req.Email != nil && *req.Email != existing.Email {
+		exists, err := s.repo.EmailExists(*req.Email)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar email: %w", err)
+		}
+		if exists {
+			return nil, errors.New("email já cadastrado")
+		}
+
+		// Email novo precisa ser verificado de novo
 		existing.Email = *req.Email
+		existing.IsEmailVerified = false
+		emailChanged = true
 	}
 	if req.Phone != nil {
 		existing.Phone = req.Phone
@@ -141,21 +229,146 @@ func (s *Service) Update(userID string, req UpdateUserRequest) (*models.User, er
 	if req.TaxID != nil {
 		existing.TaxID = req.TaxID
 	}
-	if req.RoleID != nil {
-		existing.RoleID = *req.RoleID
+	// Campos administrativos (canManage já conferido acima). Vêm depois
+	// do email para o admin poder trocar o email já como verificado.
+	deactivated := false
+	if req.IsActive != nil && *req.IsActive != existing.IsActive {
+		existing.IsActive = *req.IsActive
+		deactivated = !existing.IsActive
+	}
+	if req.IsEmailVerified != nil {
+		existing.IsEmailVerified = *req.IsEmailVerified
 	}
 
 	if err := s.repo.Update(*existing); err != nil {
 		return nil, fmt.Errorf("erro ao atualizar usuário: %w", err)
 	}
 
+	if deactivated {
+		if err := s.sessions.RevokeAllSessions(userID, "deactivated"); err != nil {
+			return nil, err
+		}
+	}
+
+	if emailChanged && !existing.IsEmailVerified {
+		s.sendVerification(existing)
+	}
+
 	existing.PasswordHash = ""
 	return existing, nil
 }
 
-// Delete - Desativa usuário
-func (s *Service) Delete(userID string) error {
-	return s.repo.Delete(userID)
+// ChangeRole troca o papel de um usuário. Quem altera precisa ter todas
+// as permissões do papel atual e do novo — users:update_role não vira
+// atalho para promover alguém (ou a si mesmo, via cúmplice) a SUPER_ADMIN.
+func (s *Service) ChangeRole(actorID string, actorRoleID int, userID string, roleID int, ipAddress string) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrOwnRoleChange
+	}
+
+	existing, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if existing == nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	exists, err := s.repo.RoleExists(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("papel não encontrado")
+	}
+
+	existing.PasswordHash = ""
+	if existing.RoleID == roleID {
+		return existing, nil
+	}
+
+	if err := s.checkRolesGrantable(actorRoleID, existing.RoleID, roleID); err != nil {
+		return nil, err
+	}
+
+	previousRoleID := existing.RoleID
+	if err := s.repo.UpdateRole(userID, roleID); err != nil {
+		return nil, err
+	}
+	existing.RoleID = roleID
+
+	// O role_id viaja no access token: força a renovação para o papel
+	// novo valer já (os refresh tokens continuam válidos).
+	if err := s.sessions.InvalidateAccessTokens(userID); err != nil {
+		return nil, fmt.Errorf("erro ao invalidar tokens: %w", err)
+	}
+
+	if err := s.audit.Log(audit.Entry{
+		ActorID:      &actorID,
+		TargetUserID: &userID,
+		Action:       audit.ActionUserRoleChanged,
+		IPAddress:    ipAddress,
+		Metadata: map[string]any{
+			"previous_role_id": previousRoleID,
+			"role_id":          roleID,
+		},
+	}); err != nil {
+		log.Printf("⚠️ Erro ao auditar troca de papel de %s: %v", userID, err)
+	}
+
+	return existing, nil
+}
+
+// checkRolesGrantable confere se o papel de quem altera tem todas as
+// permissões de cada um dos papéis envolvidos (o atribuído e o de quem é
+// alterado).
+func (s *Service) checkRolesGrantable(actorRoleID int, roleIDs ...int) error {
+	actor, err := s.roles.RolePermissions(actorRoleID)
+	if err != nil {
+		return err
+	}
+	for _, id := range roleIDs {
+		permissions, err := s.roles.RolePermissions(id)
+		if err != nil {
+			return err
+		}
+		for permission := range permissions {
+			if !actor.Has(permission) {
+				return ErrRoleNotGrantable
+			}
+		}
+	}
+	return nil
+}
+
+// checkCanActOn aplica checkRolesGrantable ao papel do usuário alvo
+// quando quem age é outra pessoa (o próprio usuário sempre pode).
+func (s *Service) checkCanActOn(actorID string, actorRoleID int, userID string) error {
+	if actorID == userID {
+		return nil
+	}
+
+	existing, err := s.repo.FindByID(userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if existing == nil {
+		return errors.New("usuário não encontrado")
+	}
+	return s.checkRolesGrantable(actorRoleID, existing.RoleID)
+}
+
+// Delete - Desativa usuário e derruba todas as sessões dele. Como em
+// Update, desativar outra pessoa exige as permissões do papel dela.
+func (s *Service) Delete(actorID string, actorRoleID int, userID string) error {
+	if err := s.checkCanActOn(actorID, actorRoleID, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(userID); err != nil {
+		return err
+	}
+	return s.sessions.RevokeAllSessions(userID, "deactivated")
 }
 
 func (s *Service) ChangePassword(userID string, req ChangePasswordRequest) error {
@@ -176,15 +389,36 @@ func (s *Service) ChangePassword(userID string, req ChangePasswordRequest) error
 		return errors.New("senha atual incorreta")
 	}
 
+	if err := s.policy.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	var previous []string
+	if s.policy.HistorySize > 1 {
+		previous, err = s.repo.PasswordHistory(userID, s.policy.HistorySize-1)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar histórico de senhas: %w", err)
+		}
+	}
+	if err := s.policy.CheckReuse(req.NewPassword, user.PasswordHash, previous); err != nil {
+		return err
+	}
+
 	newHash, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
 		return fmt.Errorf("erro ao gerar hash: %w", err)
 	}
 
-	return s.repo.UpdatePassword(userID, newHash)
+	if err := s.repo.UpdatePassword(userID, newHash); err != nil {
+		return err
+	}
+
+	// Senha nova invalida todos os refresh tokens: quem tinha a senha
+	// antiga (e uma sessão aberta) precisa logar de novo.
+	return s.sessions.RevokeAllSessions(userID, "password_changed")
 }
 
-func (s *Service) UpdateAvatar(userID, avatarURL string) (*models.User, error) {
+func (s *Service) UpdateAvatar(userID, avatarURL string, variants map[string]string) (*models.User, error) {
 	// Verificar se user existe
 	user, err := s.repo.FindByID(userID)
 	if err != nil || user == nil {
@@ -192,12 +426,14 @@ func (s *Service) UpdateAvatar(userID, avatarURL string) (*models.User, error) {
 	}
 
 	// Atualizar
-	if err := s.repo.UpdateAvatar(userID, avatarURL); err != nil {
+	if err := s.repo.UpdateAvatar(userID, avatarURL, variants); err != nil {
 		return nil, err
 	}
 
 	// Retornar usuário atualizado
+	user.PasswordHash = ""
 	user.AvatarURL = &avatarURL
+	user.AvatarVariants = variants
 	return user, nil
 }
 
@@ -0,0 +1,369 @@
+package users
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/rbac"
+)
+
+// Papéis usados nos testes: o gerente tem users:manage e users:update_role,
+// mas não as permissões do SUPER_ADMIN.
+const (
+	testRoleSuperAdmin = 1
+	testRoleUser       = 2
+	testRoleManager    = 3
+)
+
+type fakeRoles map[int]rbac.Set
+
+func (f fakeRoles) RolePermissions(roleID int) (rbac.Set, error) {
+	permissions, ok := f[roleID]
+	if !ok {
+		return nil, errors.New("papel não encontrado")
+	}
+	return permissions, nil
+}
+
+var testRoles = fakeRoles{
+	testRoleSuperAdmin: rbac.NewSet(rbac.UsersManage, rbac.UsersUpdateRole, rbac.RolesManage, rbac.UsersImpersonate),
+	testRoleUser:       rbac.NewSet(),
+	testRoleManager:    rbac.NewSet(rbac.UsersManage, rbac.UsersUpdateRole),
+}
+
+type fakeSessions struct {
+	revoked []string
+	// startedAt: sessão → momento do login
+	startedAt map[string]time.Time
+}
+
+func (f *fakeSessions) RevokeAllSessions(userID, reason string) error {
+	f.revoked = append(f.revoked, userID)
+	return nil
+}
+
+func (f *fakeSessions) InvalidateAccessTokens(userID string) error { return nil }
+
+func (f *fakeSessions) SessionStartedAt(userID, sessionID string) (*time.Time, error) {
+	startedAt, ok := f.startedAt[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &startedAt, nil
+}
+
+// fakeDB responde às consultas do Repository a partir de um mapa
+// id → role_id e registra os comandos de escrita.
+type fakeDB struct {
+	mu    sync.Mutex
+	users map[string]int
+	execs []string
+}
+
+func (f *fakeDB) writes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var writes []string
+	for _, query := range f.execs {
+		if !strings.Contains(query, "audit_logs") {
+			writes = append(writes, query)
+		}
+	}
+	return writes
+}
+
+var fakeDBs sync.Map
+
+func init() {
+	sql.Register("users_fake", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	db, ok := fakeDBs.Load(name)
+	if !ok {
+		return nil, errors.New("fakeDB não registrado")
+	}
+	return &fakeConn{db: db.(*fakeDB)}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("transações não suportadas") }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	s.db.execs = append(s.db.execs, s.query)
+	s.db.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	switch {
+	case strings.Contains(s.query, "FROM roles"):
+		_, exists := testRoles[int(args[0].(int64))]
+		return &fakeRows{columns: []string{"exists"}, values: [][]driver.Value{{exists}}}, nil
+
+	case strings.Contains(s.query, "FROM users WHERE id"):
+		id := args[0].(string)
+		roleID, ok := s.db.users[id]
+		if !ok {
+			return &fakeRows{columns: make([]string, 23)}, nil
+		}
+		now := time.Now()
+		row := []driver.Value{
+			id, id + "@example.com", "User " + id, "hash", int64(roleID), true,
+			now, now, nil, true,
+			nil, nil, nil,
+			nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil,
+		}
+		return &fakeRows{columns: make([]string, len(row)), values: [][]driver.Value{row}}, nil
+	}
+
+	return nil, errors.New("consulta inesperada: " + s.query)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newTestService monta o Service sobre um fakeDB com os usuários dados.
+func newTestService(t *testing.T, users map[string]int) (*Service, *fakeDB, *fakeSessions) {
+	t.Helper()
+
+	fake := &fakeDB{users: users}
+	fakeDBs.Store(t.Name(), fake)
+	t.Cleanup(func() { fakeDBs.Delete(t.Name()) })
+
+	db, err := sql.Open("users_fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	sessions := &fakeSessions{}
+	service := NewService(NewRepository(db), nil, sessions, nil, testRoles, audit.NewLogger(db))
+	return service, fake, sessions
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestUpdateRejectsRoleID(t *testing.T) {
+	service, fake, _ := newTestService(t, map[string]int{"2": testRoleUser})
+
+	_, err := service.Update("1", testRoleSuperAdmin, "2", UpdateUserRequest{RoleID: ptr(testRoleSuperAdmin)}, true)
+	if !errors.Is(err, ErrRoleChangeNotHere) {
+		t.Fatalf("role_id no PUT deveria ser recusado, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
+
+func TestUpdateAdminOnlyFields(t *testing.T) {
+	service, fake, _ := newTestService(t, map[string]int{"2": testRoleUser})
+
+	requests := map[string]UpdateUserRequest{
+		"is_active":         {IsActive: ptr(false)},
+		"is_email_verified": {IsEmailVerified: ptr(true)},
+	}
+	for field, req := range requests {
+		_, err := service.Update("2", testRoleUser, "2", req, false)
+		if !errors.Is(err, ErrAdminOnlyField) {
+			t.Errorf("%s sem users:manage deveria ser recusado, got %v", field, err)
+		}
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
+
+func TestUpdateHigherRoleNotAllowed(t *testing.T) {
+	service, fake, sessions := newTestService(t, map[string]int{"1": testRoleSuperAdmin})
+
+	requests := map[string]UpdateUserRequest{
+		"is_active": {IsActive: ptr(false)},
+		"email":     {Email: ptr("attacker@example.com")},
+	}
+	for field, req := range requests {
+		_, err := service.Update("3", testRoleManager, "1", req, true)
+		if !errors.Is(err, ErrRoleNotGrantable) {
+			t.Errorf("gerente alterando %s do SUPER_ADMIN deveria ser recusado, got %v", field, err)
+		}
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+	if len(sessions.revoked) > 0 {
+		t.Fatalf("nenhuma sessão deveria ter sido revogada: %v", sessions.revoked)
+	}
+}
+
+func TestUpdateGrantableRoleAllowed(t *testing.T) {
+	service, fake, sessions := newTestService(t, map[string]int{"2": testRoleUser})
+
+	user, err := service.Update("3", testRoleManager, "2", UpdateUserRequest{IsActive: ptr(false)}, true)
+	if err != nil {
+		t.Fatalf("gerente desativando USER deveria passar: %v", err)
+	}
+	if user.IsActive {
+		t.Fatal("usuário deveria estar inativo")
+	}
+	if len(fake.writes()) != 1 || len(sessions.revoked) != 1 {
+		t.Fatalf("esperado 1 update e 1 revogação, got %v / %v", fake.writes(), sessions.revoked)
+	}
+}
+
+func TestDeleteHigherRoleNotAllowed(t *testing.T) {
+	service, fake, _ := newTestService(t, map[string]int{"1": testRoleSuperAdmin})
+
+	if err := service.Delete("3", testRoleManager, "1"); !errors.Is(err, ErrRoleNotGrantable) {
+		t.Fatalf("gerente desativando SUPER_ADMIN deveria ser recusado, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
+
+func TestChangeRoleSelf(t *testing.T) {
+	service, fake, _ := newTestService(t, map[string]int{"3": testRoleManager})
+
+	_, err := service.ChangeRole("3", testRoleManager, "3", testRoleSuperAdmin, "")
+	if !errors.Is(err, ErrOwnRoleChange) {
+		t.Fatalf("troca do próprio papel deveria ser recusada, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
+
+func TestChangeRoleNotGrantable(t *testing.T) {
+	service, fake, _ := newTestService(t, map[string]int{
+		"1": testRoleSuperAdmin,
+		"2": testRoleUser,
+	})
+
+	tests := []struct {
+		name   string
+		userID string
+		roleID int
+	}{
+		{"promover a SUPER_ADMIN", "2", testRoleSuperAdmin},
+		{"rebaixar um SUPER_ADMIN", "1", testRoleUser},
+	}
+
+	for _, tt := range tests {
+		_, err := service.ChangeRole("3", testRoleManager, tt.userID, tt.roleID, "")
+		if !errors.Is(err, ErrRoleNotGrantable) {
+			t.Errorf("%s: deveria ser recusado, got %v", tt.name, err)
+		}
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
+
+func TestCheckRolesGrantable(t *testing.T) {
+	service, _, _ := newTestService(t, nil)
+
+	tests := []struct {
+		actor   int
+		roleIDs []int
+		want    error
+	}{
+		{testRoleSuperAdmin, []int{testRoleManager, testRoleUser}, nil},
+		{testRoleManager, []int{testRoleManager, testRoleUser}, nil},
+		{testRoleManager, []int{testRoleSuperAdmin}, ErrRoleNotGrantable},
+		{testRoleUser, []int{testRoleManager}, ErrRoleNotGrantable},
+	}
+
+	for _, tt := range tests {
+		if err := service.checkRolesGrantable(tt.actor, tt.roleIDs...); !errors.Is(err, tt.want) {
+			t.Errorf("ator %d, papéis %v: got %v, want %v", tt.actor, tt.roleIDs, err, tt.want)
+		}
+	}
+}
+
+func TestCreatePersonalTokenNotOwner(t *testing.T) {
+	service, fake, _ := newTestService(t, map[string]int{"1": testRoleSuperAdmin})
+
+	req := CreatePersonalTokenRequest{Name: "integração", Scopes: []string{"write"}}
+	if _, err := service.CreatePersonalToken("3", "1", req, ""); !errors.Is(err, ErrPersonalTokenNotOwner) {
+		t.Fatalf("criar token para outro usuário deveria ser recusado, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
+
+func TestScheduleDeletionHigherRoleNotAllowed(t *testing.T) {
+	service, fake, _ := newTestService(t, map[string]int{"1": testRoleSuperAdmin})
+
+	if _, err := service.ScheduleDeletion("3", testRoleManager, "", "1", "", ""); !errors.Is(err, ErrRoleNotGrantable) {
+		t.Fatalf("gerente agendando exclusão do SUPER_ADMIN deveria ser recusado, got %v", err)
+	}
+	if err := service.CancelDeletion("3", testRoleManager, "1", ""); !errors.Is(err, ErrRoleNotGrantable) {
+		t.Fatalf("gerente cancelando exclusão do SUPER_ADMIN deveria ser recusado, got %v", err)
+	}
+	if _, err := service.Export("3", testRoleManager, "1"); !errors.Is(err, ErrRoleNotGrantable) {
+		t.Fatalf("gerente exportando dados do SUPER_ADMIN deveria ser recusado, got %v", err)
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
+
+func TestScheduleDeletionSelfConfirmation(t *testing.T) {
+	service, fake, sessions := newTestService(t, map[string]int{"2": testRoleUser})
+	sessions.startedAt = map[string]time.Time{
+		"antiga": time.Now().Add(-time.Hour),
+	}
+
+	for _, sessionID := range []string{"", "antiga", "revogada"} {
+		_, err := service.ScheduleDeletion("2", testRoleUser, sessionID, "2", "", "")
+		if !errors.Is(err, ErrDeletionNotConfirmed) {
+			t.Errorf("sessão %q sem senha deveria ser recusada, got %v", sessionID, err)
+		}
+	}
+	if _, err := service.ScheduleDeletion("2", testRoleUser, "antiga", "2", "errada", ""); err == nil {
+		t.Error("senha incorreta deveria ser recusada")
+	}
+	if writes := fake.writes(); len(writes) > 0 {
+		t.Fatalf("nada deveria ter sido gravado: %v", writes)
+	}
+}
@@ -0,0 +1,101 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"loginbackend/internal/http/middleware"
+	httpresponse "loginbackend/internal/http/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreatePersonalToken
+// @Summary Criar personal access token
+// @Description Só o próprio usuário cria tokens (administradores podem listar e revogar). O token só é exibido nesta resposta. Envie-o como "Authorization: Bearer lbp_...".
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body CreatePersonalTokenRequest true "Nome, escopos e validade"
+// @Success 201 {object} Response{data=PersonalTokenResponse}
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Router /users/{id}/tokens [post]
+func (h *Handler) CreatePersonalToken(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreatePersonalTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "dados inválidos", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.service.CreatePersonalToken(claims.UserID, chi.URLParam(r, "id"), req, middleware.RemoteIP(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrPersonalTokenNotOwner) {
+			status = http.StatusForbidden
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(httpresponse.Response{
+		Message: "token criado. Guarde-o agora: ele não será exibido novamente",
+		Data:    token,
+	})
+}
+
+// ListPersonalTokens
+// @Summary Listar personal access tokens
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} Response{data=[]PersonalTokenResponse}
+// @Router /users/{id}/tokens [get]
+func (h *Handler) ListPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.service.ListPersonalTokens(chi.URLParam(r, "id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: "erro ao listar tokens"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Data: tokens})
+}
+
+// RevokePersonalToken
+// @Summary Revogar personal access token
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param tokenID path string true "ID do token"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /users/{id}/tokens/{tokenID} [delete]
+func (h *Handler) RevokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RevokePersonalToken(chi.URLParam(r, "id"), chi.URLParam(r, "tokenID")); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(httpresponse.Response{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpresponse.Response{Message: "token revogado"})
+}
@@ -0,0 +1,109 @@
+package users
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreatePersonalToken(token PersonalAccessToken) error {
+	_, err := r.db.Exec(`
+		INSERT INTO personal_access_tokens (id, user_id, name, token_prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, token.ID, token.UserID, token.Name, token.Prefix, token.TokenHash, pq.Array(token.Scopes), token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("erro ao criar token: %w", err)
+	}
+	return nil
+}
+
+// ListPersonalTokens lista os tokens ainda válidos do usuário.
+func (r *Repository) ListPersonalTokens(userID string) ([]PersonalAccessToken, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []PersonalAccessToken
+	for rows.Next() {
+		var token PersonalAccessToken
+		var lastUsedAt sql.NullTime
+
+		if err := rows.Scan(
+			&token.ID, &token.UserID, &token.Name, &token.Prefix, pq.Array(&token.Scopes),
+			&token.ExpiresAt, &lastUsedAt, &token.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao ler token: %w", err)
+		}
+
+		if lastUsedAt.Valid {
+			token.LastUsedAt = &lastUsedAt.Time
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// FindPersonalTokenByHash busca um token não expirado junto com os dados
+// do dono necessários para montar os claims.
+func (r *Repository) FindPersonalTokenByHash(tokenHash string) (*PersonalAccessToken, *personalTokenOwner, error) {
+	var token PersonalAccessToken
+	var owner personalTokenOwner
+
+	err := r.db.QueryRow(`
+		SELECT t.id, t.user_id, t.scopes, t.expires_at,
+		       u.email, u.role_id, u.is_active, u.token_version
+		FROM personal_access_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.expires_at > CURRENT_TIMESTAMP
+	`, tokenHash).Scan(
+		&token.ID, &token.UserID, pq.Array(&token.Scopes), &token.ExpiresAt,
+		&owner.Email, &owner.RoleID, &owner.IsActive, &owner.TokenVersion,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao buscar token: %w", err)
+	}
+
+	return &token, &owner, nil
+}
+
+// TouchPersonalToken registra o uso. Grava no máximo uma vez por minuto
+// para não transformar cada requisição em um UPDATE.
+func (r *Repository) TouchPersonalToken(id string) error {
+	_, err := r.db.Exec(`
+		UPDATE personal_access_tokens SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+	`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar uso do token: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeletePersonalToken(userID, id string) (bool, error) {
+	result, err := r.db.Exec(
+		`DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`,
+		id, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
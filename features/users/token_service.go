@@ -0,0 +1,148 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const defaultPersonalTokenDays = 30
+
+var errInvalidPersonalToken = errors.New("token inválido ou expirado")
+
+// ErrPersonalTokenNotOwner: um token autentica como o dono, então
+// criá-lo para outra pessoa seria uma personificação sem rastro.
+var ErrPersonalTokenNotOwner = errors.New("apenas o próprio usuário pode criar personal access tokens")
+
+// CreatePersonalToken gera um token de longa duração para integrações.
+// O valor em texto só é devolvido aqui. Só o dono cria (actorID ==
+// userID); administradores podem listar e revogar.
+func (s *Service) CreatePersonalToken(actorID, userID string, req CreatePersonalTokenRequest, ipAddress string) (*PersonalTokenResponse, error) {
+	if actorID != userID {
+		return nil, ErrPersonalTokenNotOwner
+	}
+
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("dados inválidos: %w", err)
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return nil, errors.New("usuário não encontrado")
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultPersonalTokenDays
+	}
+
+	value, prefix, err := utils.GeneratePersonalToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := PersonalAccessToken{
+		ID:        utils.GenerateSnowflakeID(),
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		TokenHash: utils.HashToken(value),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		ExpiresAt: time.Now().Add(time.Duration(days) * 24 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreatePersonalToken(token); err != nil {
+		return nil, err
+	}
+
+	s.logAudit(actorID, userID, audit.ActionPersonalTokenCreated, ipAddress, map[string]any{
+		"token_id":   token.ID,
+		"name":       token.Name,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt,
+	})
+
+	response := toPersonalTokenResponse(token)
+	response.Token = value
+	return &response, nil
+}
+
+func (s *Service) ListPersonalTokens(userID string) ([]PersonalTokenResponse, error) {
+	tokens, err := s.repo.ListPersonalTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]PersonalTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, toPersonalTokenResponse(token))
+	}
+	return response, nil
+}
+
+func (s *Service) RevokePersonalToken(userID, tokenID string) error {
+	deleted, err := s.repo.DeletePersonalToken(userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("token não encontrado")
+	}
+	return nil
+}
+
+// ValidatePersonalToken implementa middleware.PersonalTokenValidator.
+// Os claims levam a época atual do usuário, então "sair de todos" não
+// derruba o token — para isso ele precisa ser revogado (a troca de senha
+// revoga todos, ver auth.RevokeAllSessions).
+func (s *Service) ValidatePersonalToken(value string) (*utils.TokenClaims, error) {
+	token, owner, err := s.repo.FindPersonalTokenByHash(utils.HashToken(value))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || !owner.IsActive {
+		return nil, errInvalidPersonalToken
+	}
+
+	if err := s.repo.TouchPersonalToken(token.ID); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+
+	return &utils.TokenClaims{
+		UserID:          token.UserID,
+		Email:           owner.Email,
+		RoleID:          owner.RoleID,
+		TokenVersion:    owner.TokenVersion,
+		Scope:           strings.Join(token.Scopes, " "),
+		PersonalTokenID: token.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        token.ID,
+			Subject:   token.UserID,
+			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+		},
+	}, nil
+}
+
+func toPersonalTokenResponse(token PersonalAccessToken) PersonalTokenResponse {
+	return PersonalTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
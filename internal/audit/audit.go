@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Ações registradas em audit_logs.action
+const (
+	ActionAccountLocked   = "account_locked"
+	ActionAccountUnlocked = "account_unlocked"
+
+	ActionImpersonationStarted = "impersonation_started"
+	ActionImpersonatedRequest  = "impersonated_request"
+
+	ActionRoleCreated = "role_created"
+	ActionRoleUpdated = "role_updated"
+	ActionRoleDeleted = "role_deleted"
+
+	ActionUserRoleChanged = "user_role_changed"
+	ActionUserActivated   = "user_activated"
+	ActionUserDeactivated = "user_deactivated"
+	ActionUserImported    = "user_imported"
+
+	ActionInvitationCreated  = "invitation_created"
+	ActionInvitationRevoked  = "invitation_revoked"
+	ActionInvitationAccepted = "invitation_accepted"
+
+	ActionAccountDeletionScheduled = "account_deletion_scheduled"
+	ActionAccountDeletionCancelled = "account_deletion_cancelled"
+	ActionAccountAnonymized        = "account_anonymized"
+
+	ActionPersonalTokenCreated = "personal_token_created"
+)
+
+// Entry é um evento de segurança. ActorID nil significa "o próprio
+// sistema" (ex: bloqueio automático após tentativas de login).
+type Entry struct {
+	ActorID      *string
+	TargetUserID *string
+	Action       string
+	IPAddress    string
+	Metadata     map[string]any
+}
+
+// Logger grava eventos na tabela audit_logs. É compartilhado entre as
+// features que precisam deixar rastro (auth, users, ...).
+type Logger struct {
+	db *sql.DB
+}
+
+func NewLogger(db *sql.DB) *Logger {
+	return &Logger{db: db}
+}
+
+// Log grava o evento. Quem chama decide se uma falha aqui deve ou não
+// interromper a operação auditada.
+func (l *Logger) Log(entry Entry) error {
+	metadataJSON := []byte("{}")
+	if entry.Metadata != nil {
+		var err error
+		metadataJSON, err = json.Marshal(entry.Metadata)
+		if err != nil {
+			return fmt.Errorf("erro ao converter metadata para json: %w", err)
+		}
+	}
+
+	var ipAddress sql.NullString
+	if entry.IPAddress != "" {
+		ipAddress = sql.NullString{String: entry.IPAddress, Valid: true}
+	}
+
+	_, err := l.db.Exec(`
+		INSERT INTO audit_logs (actor_id, target_user_id, action, ip_address, metadata)
+		VALUES ($1, $2, $3, $4, $5)
+	`, entry.ActorID, entry.TargetUserID, entry.Action, ipAddress, metadataJSON)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar auditoria: %w", err)
+	}
+
+	return nil
+}
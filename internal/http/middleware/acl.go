@@ -6,6 +6,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	pkgacl "loginbackend/pkg/acl"
+	"loginbackend/pkg/rbac"
 )
 
 // ACLService interface para evitar import circular
@@ -14,7 +15,7 @@ type ACLService interface {
 }
 
 // RequirePermission verifica se o usuário tem permissão para acessar o recurso
-func RequirePermission(aclService ACLService, resourceType pkgacl.ResourceType, requiredPerm pkgacl.Permission) func(http.Handler) http.Handler {
+func RequirePermission(aclService ACLService, permissions PermissionResolver, resourceType pkgacl.ResourceType, requiredPerm pkgacl.Permission) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims := GetUserFromContext(r.Context())
@@ -29,6 +30,12 @@ func RequirePermission(aclService ACLService, resourceType pkgacl.ResourceType,
 				return
 			}
 
+			// acl:admin ignora as ACLs (suporte/administração)
+			if HasSystemPermission(permissions, claims, rbac.ACLAdmin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			hasPermission, err := aclService.CheckPermission(
 				claims.UserID,
 				resourceID,
@@ -52,7 +59,7 @@ func RequirePermission(aclService ACLService, resourceType pkgacl.ResourceType,
 }
 
 // RequireOwnerOrShared verifica se usuário é owner OU tem permissão via ACL
-func RequireOwnerOrShared(aclService ACLService, resourceType pkgacl.ResourceType, requiredPerm pkgacl.Permission) func(http.Handler) http.Handler {
+func RequireOwnerOrShared(aclService ACLService, permissions PermissionResolver, resourceType pkgacl.ResourceType, requiredPerm pkgacl.Permission) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims := GetUserFromContext(r.Context())
@@ -67,6 +74,12 @@ func RequireOwnerOrShared(aclService ACLService, resourceType pkgacl.ResourceTyp
 				return
 			}
 
+			// acl:admin ignora as ACLs (suporte/administração)
+			if HasSystemPermission(permissions, claims, rbac.ACLAdmin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			hasPermission, err := aclService.CheckPermission(
 				claims.UserID,
 				resourceID,
@@ -90,16 +103,16 @@ func RequireOwnerOrShared(aclService ACLService, resourceType pkgacl.ResourceTyp
 }
 
 // RequireCanShare - Middleware específico
-func RequireCanShare(aclService ACLService, resourceType pkgacl.ResourceType) func(http.Handler) http.Handler {
-	return RequirePermission(aclService, resourceType, pkgacl.PermissionShare)
+func RequireCanShare(aclService ACLService, permissions PermissionResolver, resourceType pkgacl.ResourceType) func(http.Handler) http.Handler {
+	return RequirePermission(aclService, permissions, resourceType, pkgacl.PermissionShare)
 }
 
 // RequireCanDelete - Middleware específico
-func RequireCanDelete(aclService ACLService, resourceType pkgacl.ResourceType) func(http.Handler) http.Handler {
-	return RequirePermission(aclService, resourceType, pkgacl.PermissionDelete)
+func RequireCanDelete(aclService ACLService, permissions PermissionResolver, resourceType pkgacl.ResourceType) func(http.Handler) http.Handler {
+	return RequirePermission(aclService, permissions, resourceType, pkgacl.PermissionDelete)
 }
 
 // RequireCanWrite - Middleware específico
-func RequireCanWrite(aclService ACLService, resourceType pkgacl.ResourceType) func(http.Handler) http.Handler {
-	return RequirePermission(aclService, resourceType, pkgacl.PermissionWrite)
+func RequireCanWrite(aclService ACLService, permissions PermissionResolver, resourceType pkgacl.ResourceType) func(http.Handler) http.Handler {
+	return RequirePermission(aclService, permissions, resourceType, pkgacl.PermissionWrite)
 }
@@ -3,8 +3,10 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"loginbackend/pkg/jwtkeys"
 	"loginbackend/pkg/utils"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/redis/go-redis/v9"
@@ -16,7 +18,15 @@ const (
 	UserContextKey contextKey = "user"
 )
 
-func AuthMiddleware(jwtSecret string, redisClient *redis.Client) func(http.Handler) http.Handler {
+// PersonalTokenValidator resolve um personal access token para os claims
+// do dono. Interface para não importar 'users' aqui.
+type PersonalTokenValidator interface {
+	ValidatePersonalToken(token string) (*utils.TokenClaims, error)
+}
+
+// AuthMiddleware aceita access tokens JWT e, se tokens não for nil,
+// personal access tokens (prefixo utils.PersonalTokenPrefix).
+func AuthMiddleware(keys *jwtkeys.Manager, redisClient *redis.Client, tokens PersonalTokenValidator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			tokenString := ""
@@ -42,28 +52,126 @@ func AuthMiddleware(jwtSecret string, redisClient *redis.Client) func(http.Handl
 				return
 			}
 
-			// 🚨 Verificar Blacklist no Redis (Código existente mantido)
-			ctx := r.Context()
-			blacklistKey := fmt.Sprintf("blacklist:%s", tokenString)
-			exists, err := redisClient.Exists(ctx, blacklistKey).Result()
-			if err == nil && exists > 0 {
-				http.Error(w, "Token revoked (logout)", http.StatusUnauthorized)
+			// Personal access token: validado no banco, escopo checado aqui
+			if strings.HasPrefix(tokenString, utils.PersonalTokenPrefix) {
+				if tokens == nil {
+					http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+					return
+				}
+
+				claims, err := tokens.ValidatePersonalToken(tokenString)
+				if err != nil {
+					http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+					return
+				}
+
+				if !personalTokenAllows(claims.Scope, r.Method) {
+					http.Error(w, "Forbidden: token sem escopo write", http.StatusForbidden)
+					return
+				}
+
+				userCtx := context.WithValue(r.Context(), UserContextKey, claims)
+				next.ServeHTTP(w, r.WithContext(userCtx))
 				return
 			}
 
 			// Validar token (Assinatura JWT)
-			claims, err := utils.ValidateJWT(tokenString, jwtSecret)
+			claims, err := utils.ValidateJWT(tokenString, keys)
 			if err != nil {
 				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 				return
 			}
 
+			// Tokens client_credentials (sem usuário) são para outros serviços.
+			// Tokens de clientes OAuth agem só dentro dos escopos concedidos,
+			// que valem apenas em OAuthClientMiddleware (userinfo).
+			if claims.UserID == "" || claims.ClientID != "" {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			if msg := revoked(r.Context(), redisClient, claims); msg != "" {
+				http.Error(w, msg, http.StatusUnauthorized)
+				return
+			}
+
+			recordAuth(r.Context(), claims)
 			userCtx := context.WithValue(r.Context(), UserContextKey, claims)
 			next.ServeHTTP(w, r.WithContext(userCtx))
 		})
 	}
 }
 
+// OAuthClientMiddleware aceita só access tokens emitidos pelo servidor
+// OAuth para um cliente em nome de um usuário. As rotas atrás dele devem
+// conferir claims.Scope.
+func OAuthClientMiddleware(keys *jwtkeys.Manager, redisClient *redis.Client) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+			if !found || tokenString == "" {
+				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := utils.ValidateJWT(tokenString, keys)
+			if err != nil || claims.UserID == "" || claims.ClientID == "" {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			if msg := revoked(r.Context(), redisClient, claims); msg != "" {
+				http.Error(w, msg, http.StatusUnauthorized)
+				return
+			}
+
+			userCtx := context.WithValue(r.Context(), UserContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(userCtx))
+		})
+	}
+}
+
+// revoked confere as revogações guardadas no Redis e devolve o motivo,
+// ou "" se o token continua valendo.
+func revoked(ctx context.Context, redisClient *redis.Client, claims *utils.TokenClaims) string {
+	// 🚨 Verificar Blacklist no Redis (por jti)
+	blacklistKey := fmt.Sprintf("blacklist:jti:%s", claims.ID)
+	exists, err := redisClient.Exists(ctx, blacklistKey).Result()
+	if err == nil && exists > 0 {
+		return "Token revoked (logout)"
+	}
+
+	// Sessão revogada (logout do dispositivo, reuso de refresh token):
+	// invalida todos os access tokens emitidos para ela.
+	if claims.SessionID != "" {
+		sessionKey := fmt.Sprintf("blacklist:session:%s", claims.SessionID)
+		exists, err := redisClient.Exists(ctx, sessionKey).Result()
+		if err == nil && exists > 0 {
+			return "Session revoked"
+		}
+	}
+
+	// Época do usuário ("sair de todos", troca de senha, desativação):
+	// só existe no Redis enquanto houver token antigo ainda vivo.
+	versionKey := fmt.Sprintf("token_version:%s", claims.UserID)
+	version, err := redisClient.Get(ctx, versionKey).Int()
+	if err == nil && claims.TokenVersion < version {
+		return "Token revoked"
+	}
+
+	return ""
+}
+
+// personalTokenAllows: "read" cobre só métodos sem efeito colateral.
+func personalTokenAllows(scope, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return slices.Contains(strings.Fields(scope), utils.PersonalTokenScopeWrite)
+}
+
 // GetUserFromContext obtém os claims do usuário do context
 func GetUserFromContext(ctx context.Context) *utils.TokenClaims {
 	if claims, ok := ctx.Value(UserContextKey).(*utils.TokenClaims); ok {
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"loginbackend/internal/audit"
+	"loginbackend/pkg/utils"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+const authRecorderKey contextKey = "auth_recorder"
+
+// authRecorder deixa o AuthMiddleware (que roda dentro das rotas) contar
+// a quem está fora quem foi autenticado na requisição.
+type authRecorder struct {
+	claims *utils.TokenClaims
+}
+
+func recordAuth(ctx context.Context, claims *utils.TokenClaims) {
+	if recorder, ok := ctx.Value(authRecorderKey).(*authRecorder); ok {
+		recorder.claims = claims
+	}
+}
+
+// AuditImpersonation grava em audit_logs toda requisição feita com um
+// token de personificação (claim "act"). Deve ser registrado no router
+// raiz, antes das rotas das features.
+func AuditImpersonation(logger *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &authRecorder{}
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), authRecorderKey, recorder)))
+
+			claims := recorder.claims
+			if claims == nil || claims.Act == nil {
+				return
+			}
+
+			adminID := claims.ImpersonatorID()
+			if err := logger.Log(audit.Entry{
+				ActorID:      &adminID,
+				TargetUserID: &claims.UserID,
+				Action:       audit.ActionImpersonatedRequest,
+				IPAddress:    RemoteIP(r),
+				Metadata: map[string]any{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"status": ww.Status(),
+					"jti":    claims.ID,
+				},
+			}); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+		})
+	}
+}
+
+// DenyImpersonation bloqueia rotas sensíveis (senha, tokens, MFA,
+// sessões) para quem está personificando outro usuário.
+func DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := GetUserFromContext(r.Context())
+		if claims != nil && claims.Act != nil {
+			http.Error(w, "Forbidden: operação não permitida durante personificação", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DenyPersonalToken bloqueia rotas que um personal access token não pode
+// usar: emitir outros tokens (um token vazado se renovaria para sempre)
+// e trocar a senha.
+func DenyPersonalToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := GetUserFromContext(r.Context())
+		if claims != nil && claims.PersonalTokenID != "" {
+			http.Error(w, "Forbidden: operação não permitida com personal access token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"loginbackend/pkg/rbac"
+	"loginbackend/pkg/utils"
+)
+
+// PermissionResolver devolve as permissões de sistema de um papel
+// (implementado por roles.Service, com cache).
+type PermissionResolver interface {
+	RolePermissions(roleID int) (rbac.Set, error)
+}
+
+// HasSystemPermission indica se o papel do token tem a permissão. Erro ao
+// resolver conta como "não tem" (falha fechada).
+func HasSystemPermission(resolver PermissionResolver, claims *utils.TokenClaims, permission string) bool {
+	if claims == nil {
+		return false
+	}
+
+	permissions, err := resolver.RolePermissions(claims.RoleID)
+	if err != nil {
+		log.Printf("⚠️ Erro ao buscar permissões do papel %d: %v", claims.RoleID, err)
+		return false
+	}
+	return permissions.Has(permission)
+}
+
+// RequireSystemPermission libera a rota só para papéis com a permissão
+// (ex: RequireSystemPermission(roles, rbac.UsersList)).
+func RequireSystemPermission(resolver PermissionResolver, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUserFromContext(r.Context())
+			if claims == nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !HasSystemPermission(resolver, claims, permission) {
+				http.Error(w, "Forbidden: access denied", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package http
 
 import (
+	"errors"
+	"io"
+	"log"
 	"loginbackend/config"
+	"loginbackend/internal/audit"
 	"loginbackend/internal/http/middleware"
 	"loginbackend/internal/http/ratelimit"
+	"loginbackend/pkg/storage"
+	"loginbackend/pkg/uploader"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,7 +22,7 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-func NewRouter(cfg *config.Config, redisClient *redis.Client) *chi.Mux {
+func NewRouter(cfg *config.Config, redisClient *redis.Client, auditLogger *audit.Logger, store storage.Storage) *chi.Mux {
 	r := chi.NewRouter()
 
 	origins := cfg.AllowedOrigins
@@ -28,6 +33,10 @@ func NewRouter(cfg *config.Config, redisClient *redis.Client) *chi.Mux {
 	// SECURITY HEADERS
 	r.Use(middleware.SecurityHeaders)
 
+	// IP de origem (auditoria, sessões, bloqueio de login): cabeçalhos de
+	// proxy só valem vindos de TRUSTED_PROXIES
+	r.Use(middleware.ClientIP(cfg.TrustedProxies))
+
 	// Rate Limit GLOBAL (DDoS Protection)
 	r.Use(httprate.Limit(
 		100,
@@ -45,29 +54,50 @@ func NewRouter(cfg *config.Config, redisClient *redis.Client) *chi.Mux {
 		MaxAge:           300,
 	}))
 
-	workDir, _ := os.Getwd()
-	uploadDir := filepath.Join(workDir, "uploads")
+	// Toda requisição feita com token de personificação vai para audit_logs
+	r.Use(middleware.AuditImpersonation(auditLogger))
 
-	// Rota para arquivos
-	FileServer(r, "/uploads", http.Dir(uploadDir))
+	// Arquivos enviados (avatares), lidos do storage configurado
+	FileServer(r, "/uploads", store)
 
 	return r
 }
 
-func FileServer(r chi.Router, path string, root http.FileSystem) {
+// FileServer serve as chaves do storage em path/*. Nomes versionados (com
+// hash do conteúdo) podem ficar em cache para sempre; os demais, não.
+func FileServer(r chi.Router, path string, store storage.Storage) {
 	if strings.ContainsAny(path, "{}*") {
 		panic("FileServer não permite parâmetros de URL")
 	}
 
-	fs := http.StripPrefix(path, http.FileServer(root))
+	r.Get(strings.TrimRight(path, "/")+"/*", func(w http.ResponseWriter, r *http.Request) {
+		key := chi.URLParam(r, "*")
+		if !storage.ValidKey(key) {
+			http.NotFound(w, r)
+			return
+		}
 
-	if path != "/" && path[len(path)-1] != '/' {
-		r.Get(path, http.RedirectHandler(path+"/", 301).ServeHTTP)
-		path += "/"
-	}
-	path += "*"
+		body, info, err := store.Get(key)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Erro ao ler %s do storage: %v", key, err)
+			http.Error(w, "erro ao ler arquivo", http.StatusInternalServerError)
+			return
+		}
+		defer body.Close()
 
-	r.Get(path, func(w http.ResponseWriter, r *http.Request) {
-		fs.ServeHTTP(w, r)
+		w.Header().Set("Content-Type", info.ContentType)
+		if info.Size > 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+		}
+		if uploader.IsVersioned(key) {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		io.Copy(w, body)
 	})
 }
@@ -0,0 +1,22 @@
+-- Migration v0.05 - Autenticação em dois fatores (TOTP)
+-- users.totp_secret já existia desde a v0.01, mas nunca foi usado.
+-- O segredo é gravado no setup e só passa a valer após a confirmação
+-- com o primeiro código (totp_enabled = true).
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN DEFAULT false;
+
+-- ============================================
+-- CÓDIGOS DE RECUPERAÇÃO (uso único)
+-- ============================================
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL, -- SHA-256 hex do código normalizado
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_recovery_code UNIQUE (user_id, code_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON user_recovery_codes(user_id) WHERE used_at IS NULL;
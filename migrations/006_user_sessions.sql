@@ -0,0 +1,23 @@
+-- Migration v0.06 - Sessões por dispositivo
+-- Substitui a coluna única users.refresh_token (em texto puro) por uma
+-- sessão por dispositivo, guardando apenas o hash SHA-256 do token.
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT PRIMARY KEY, -- Snowflake ID gerado pelo Go
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+
+    -- Identificação do dispositivo (apenas informativo para o usuário)
+    user_agent TEXT,
+    ip_address VARCHAR(45), -- comporta IPv6
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id) WHERE revoked_at IS NULL;
+
+-- Tokens antigos estavam em texto puro: invalidamos todos (força novo login).
+UPDATE users SET refresh_token = NULL WHERE refresh_token IS NOT NULL;
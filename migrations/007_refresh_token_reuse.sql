@@ -0,0 +1,30 @@
+-- Migration v0.07 - Detecção de reuso de refresh token
+-- Cada sessão (user_sessions) é uma "família" de tokens: a cada refresh
+-- o token é rotacionado e o hash antigo fica registrado aqui. Se um
+-- hash antigo voltar a ser apresentado, o token foi copiado por alguém
+-- e a família inteira é revogada.
+
+CREATE TABLE IF NOT EXISTS refresh_token_history (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+    rotated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_history_session ON refresh_token_history(session_id);
+
+ALTER TABLE user_sessions
+ADD COLUMN IF NOT EXISTS revoked_reason VARCHAR(30); -- logout, user_revoked, reuse_detected
+
+-- ============================================
+-- JOB DE LIMPEZA (Cronjob externo ou pg_cron)
+-- ============================================
+-- Sessões expiradas/revogadas há mais de 30 dias não servem mais nem
+-- para detecção de reuso: o token já teria expirado de qualquer forma.
+CREATE OR REPLACE FUNCTION cleanup_expired_sessions()
+RETURNS void AS $$
+BEGIN
+    DELETE FROM user_sessions
+    WHERE expires_at < NOW() - INTERVAL '30 days'
+       OR revoked_at < NOW() - INTERVAL '30 days';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,23 @@
+-- Migration v0.08 - Bloqueio de conta por tentativas de login + auditoria
+-- Os contadores de falha vivem no Redis (efêmeros); o bloqueio em si é
+-- gravado no usuário para ficar visível ao admin e sobreviver a restart.
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+-- ============================================
+-- TRILHA DE AUDITORIA (eventos de segurança)
+-- ============================================
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT REFERENCES users(id),       -- quem fez (NULL = sistema)
+    target_user_id BIGINT REFERENCES users(id), -- quem foi afetado
+    action VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45),
+    metadata JSONB DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs(target_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs(actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action, created_at DESC);
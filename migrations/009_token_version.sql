@@ -0,0 +1,7 @@
+-- Migration v0.09 - Versão (época) dos tokens por usuário
+-- Todo access token carrega a versão vigente no momento da emissão
+-- (claim "tv"). Incrementar a coluna invalida de uma vez todos os access
+-- tokens já emitidos para o usuário ("sair de todos os dispositivos").
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,19 @@
+-- Migration v0.10 - Login social (OpenID Connect)
+-- Liga o "sub" de um provedor externo (Google, Microsoft, ...) a um
+-- usuário local. Um usuário pode ter no máximo uma identidade por
+-- provedor, e uma identidade externa pertence a um único usuário.
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT PRIMARY KEY,                        -- Snowflake
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,                -- nome configurado em OIDC_PROVIDERS
+    subject VARCHAR(255) NOT NULL,                -- claim "sub" do id_token
+    email VARCHAR(255),                           -- email informado pelo provedor no vínculo
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP,
+
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
@@ -0,0 +1,35 @@
+-- Migration v0.11 - Servidor de autorização OAuth2/OIDC
+-- Nossos SPAs e CLIs internos delegam o login a esta API.
+
+-- ============================================
+-- CLIENTES REGISTRADOS
+-- ============================================
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id VARCHAR(64) PRIMARY KEY,                  -- client_id público
+    name VARCHAR(100) NOT NULL,
+    secret_hash VARCHAR(64),                     -- SHA-256; NULL = cliente público (SPA/CLI, exige PKCE)
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',  -- comparação exata
+    scopes TEXT[] NOT NULL DEFAULT '{}',         -- escopos que o cliente pode pedir
+    grant_types TEXT[] NOT NULL DEFAULT '{}',    -- authorization_code, refresh_token, client_credentials
+    created_by BIGINT REFERENCES users(id),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================
+-- CONSENTIMENTO (usuário x cliente)
+-- ============================================
+CREATE TABLE IF NOT EXISTS oauth_consents (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    granted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, client_id)
+);
+
+-- Refresh tokens emitidos para clientes OAuth reaproveitam as sessões
+-- (rotação + detecção de reuso). client_id NULL = login do próprio app.
+ALTER TABLE user_sessions
+ADD COLUMN IF NOT EXISTS client_id VARCHAR(64) REFERENCES oauth_clients(id) ON DELETE CASCADE,
+ADD COLUMN IF NOT EXISTS scope TEXT;
@@ -0,0 +1,19 @@
+-- Migration v0.12 - Passkeys (WebAuthn)
+-- Cada linha é um autenticador (chave de segurança, celular, Touch ID...)
+-- cadastrado pelo usuário. A passkey pode ser o fator único do login ou
+-- o segundo fator depois da senha.
+
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id BIGINT PRIMARY KEY,                        -- Snowflake
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id BYTEA NOT NULL UNIQUE,          -- id gerado pelo autenticador
+    public_key BYTEA NOT NULL,                    -- chave pública em DER (PKIX)
+    algorithm INTEGER NOT NULL,                   -- algoritmo COSE (-7 ES256, -8 EdDSA, -257 RS256)
+    sign_count BIGINT NOT NULL DEFAULT 0,         -- contador anti-clonagem
+    transports TEXT[] NOT NULL DEFAULT '{}',      -- dicas para o navegador (usb, nfc, internal, hybrid...)
+    name VARCHAR(100) NOT NULL,                   -- apelido dado pelo usuário
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user ON webauthn_credentials(user_id);
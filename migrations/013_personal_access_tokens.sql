@@ -0,0 +1,18 @@
+-- Migration v0.13 - Personal access tokens
+-- Tokens de longa duração para integrações (scripts, CI, ...), usados no
+-- lugar do par login/refresh. Só o hash é guardado; token_prefix é o
+-- trecho inicial exibido na listagem para o usuário reconhecer o token.
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id BIGINT PRIMARY KEY,                        -- Snowflake
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_prefix VARCHAR(16) NOT NULL,            -- ex: lbp_AbCd1234
+    token_hash VARCHAR(64) NOT NULL UNIQUE,       -- SHA-256 (hex) do token
+    scopes TEXT[] NOT NULL DEFAULT '{}',          -- read, write
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user ON personal_access_tokens(user_id);
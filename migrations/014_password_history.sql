@@ -0,0 +1,12 @@
+-- Migration v0.14 - Histórico de senhas
+-- A cada troca, o hash que sai de users.password_hash é guardado aqui,
+-- para a política de senha impedir a reutilização das últimas N.
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history(user_id, created_at DESC);
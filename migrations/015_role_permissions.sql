@@ -0,0 +1,31 @@
+-- Migration v0.15 - Permissões de sistema por papel
+-- Substitui o "role_id = 1" espalhado pelo código: cada rota administrativa
+-- exige uma permissão nomeada (users:list, acl:admin, ...) e os papéis
+-- passam a ser só dados. Novos papéis (SUPPORT, AUDITOR, ...) são criados
+-- pela API, sem deploy.
+
+ALTER TABLE roles
+ADD COLUMN IF NOT EXISTS is_system BOOLEAN DEFAULT false;
+
+ALTER TABLE roles
+ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+-- SUPER_ADMIN e USER são usados pelo código (seed do admin e cadastro
+-- público) e não podem ser removidos.
+UPDATE roles SET is_system = true WHERE id IN (1, 2);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles(role_name);
+
+-- O SERIAL ainda está em 1: os ids 1 e 2 foram inseridos à mão.
+SELECT setval(pg_get_serial_sequence('roles', 'id'), GREATEST((SELECT MAX(id) FROM roles), 1));
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role_id, permission)
+);
+
+-- SUPER_ADMIN mantém o acesso total de antes
+INSERT INTO role_permissions (role_id, permission) VALUES (1, '*')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,13 @@
+-- Migration v0.16 - Exclusão de conta (LGPD/GDPR)
+-- A exclusão pedida pelo usuário fica agendada por um período de carência
+-- (pode ser cancelada). Ao fim dele, a conta é anonimizada: os dados
+-- pessoais são apagados, mas a linha em users continua existindo para
+-- que tasks, task_events, ACLs e auditoria sigam referencialmente válidos.
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS deletion_scheduled_for TIMESTAMP,
+ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled ON users(deletion_scheduled_for)
+    WHERE deletion_scheduled_for IS NOT NULL AND anonymized_at IS NULL;
@@ -0,0 +1,8 @@
+-- Migration v0.17 - Listagem administrativa de usuários
+-- Índices para os filtros e ordenações do console (GET /users). A
+-- paginação por cursor usa (coluna de ordenação, id) como chave.
+
+CREATE INDEX IF NOT EXISTS idx_users_role_id ON users(role_id);
+CREATE INDEX IF NOT EXISTS idx_users_last_login_at ON users(last_login_at);
+CREATE INDEX IF NOT EXISTS idx_users_name_id ON users(name, id);
+CREATE INDEX IF NOT EXISTS idx_users_email_id ON users(email, id);
@@ -0,0 +1,28 @@
+-- Migration v0.18 - Convites de cadastro
+-- Com SIGNUP_MODE=invite (ou domain), contas novas entram por convite:
+-- um código de uso único enviado por email, criado por quem tem
+-- users:invite ou pelo admin de um time (só para o próprio time).
+-- Guardamos apenas o hash do código.
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id BIGINT PRIMARY KEY,                          -- Snowflake
+    email VARCHAR(255) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL UNIQUE,
+    role_id INT NOT NULL REFERENCES roles(id),
+    team_id BIGINT REFERENCES teams(id) ON DELETE SET NULL,
+    team_role VARCHAR(20) CHECK (team_role IN ('Admin', 'Member', 'Viewer')),
+    invited_by BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'revoked')),
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_user_id BIGINT REFERENCES users(id),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Um convite pendente por email (um novo substitui o anterior)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_pending_email
+    ON invitations(LOWER(email)) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_invitations_invited_by ON invitations(invited_by);
@@ -0,0 +1,7 @@
+-- Migration v0.19 - Variantes do avatar
+-- O upload gera vários tamanhos com nome pelo hash do conteúdo
+-- ({userID}-{hash}-{tamanho}.jpg). avatar_url continua apontando para o
+-- tamanho padrão; avatar_variants guarda {"64": url, "256": url, ...}.
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS avatar_variants JSONB;
@@ -0,0 +1,354 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits é o tamanho mínimo aceito para chaves RSA.
+const minRSABits = 2048
+
+// Key é uma chave de verificação publicada no JWKS. Só a chave de
+// assinatura atual tem a parte privada carregada.
+type Key struct {
+	ID        string
+	Algorithm string
+	public    crypto.PublicKey
+}
+
+// Manager assina tokens com UMA chave e aceita na validação qualquer
+// chave registrada, o que permite rotacionar sem derrubar os tokens
+// emitidos pela chave anterior:
+//
+//  1. publica a nova chave como verificação (JWKS) e espera os
+//     consumidores atualizarem o cache;
+//  2. promove a nova a chave de assinatura e mantém a antiga como
+//     verificação até expirarem os tokens assinados por ela;
+//  3. remove a antiga.
+//
+// Sem PEM configurado cai no modo HS256 legado (JWT_SECRET), que não
+// publica nada no JWKS.
+type Manager struct {
+	signingKey    crypto.Signer
+	signingID     string
+	signingMethod jwt.SigningMethod
+	hmacSecret    []byte
+
+	keys []Key
+
+	issuer   string
+	audience string
+}
+
+// NewHMAC cria um Manager no modo legado, com segredo compartilhado.
+func NewHMAC(secret string) *Manager {
+	return &Manager{
+		signingMethod: jwt.SigningMethodHS256,
+		hmacSecret:    []byte(secret),
+	}
+}
+
+// LoadFromFiles carrega a chave privada de assinatura e, opcionalmente,
+// chaves públicas (ou privadas) antigas que ainda devem ser aceitas.
+func LoadFromFiles(signingKeyPath string, verificationKeyPaths []string) (*Manager, error) {
+	signer, err := loadPrivateKey(signingKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := newKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("chave de assinatura %s: %w", signingKeyPath, err)
+	}
+
+	m := &Manager{
+		signingKey: signer,
+		signingID:  signingKey.ID,
+		keys:       []Key{signingKey},
+	}
+	if signingKey.Algorithm == "RS256" {
+		m.signingMethod = jwt.SigningMethodRS256
+	} else {
+		m.signingMethod = jwt.SigningMethodEdDSA
+	}
+
+	for _, path := range verificationKeyPaths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		public, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := newKey(public)
+		if err != nil {
+			return nil, fmt.Errorf("chave de verificação %s: %w", path, err)
+		}
+		if m.findKey(key.ID) != nil {
+			continue
+		}
+		m.keys = append(m.keys, key)
+	}
+
+	return m, nil
+}
+
+// SetIssuer define os claims iss/aud emitidos e exigidos na validação.
+// Vazio desliga a checagem correspondente.
+func (m *Manager) SetIssuer(issuer, audience string) {
+	m.issuer = issuer
+	m.audience = audience
+}
+
+// Issuer retorna o iss configurado.
+func (m *Manager) Issuer() string {
+	return m.issuer
+}
+
+// Audience retorna o aud configurado.
+func (m *Manager) Audience() string {
+	return m.audience
+}
+
+// Sign assina os claims com a chave atual, incluindo o kid no header.
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(m.signingMethod, claims)
+
+	if m.hmacSecret != nil {
+		return token.SignedString(m.hmacSecret)
+	}
+
+	token.Header["kid"] = m.signingID
+	return token.SignedString(m.signingKey)
+}
+
+// Keyfunc resolve a chave de verificação pelo kid do header. Também
+// garante que o alg do token é o da chave — sem isso um token poderia
+// ser "assinado" com a chave pública usada como segredo HMAC.
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if m.hmacSecret != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("método de assinatura inválido: %v", token.Header["alg"])
+		}
+		return m.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token sem kid")
+	}
+
+	key := m.findKey(kid)
+	if key == nil {
+		return nil, fmt.Errorf("kid desconhecido: %s", kid)
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("método de assinatura inválido: %v", token.Header["alg"])
+	}
+
+	return key.public, nil
+}
+
+// ParserOptions reúne as regras de validação: algoritmos aceitos,
+// exp obrigatório e, se configurados, iss/aud.
+func (m *Manager) ParserOptions() []jwt.ParserOption {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(m.ValidMethods()),
+		jwt.WithExpirationRequired(),
+	}
+	if m.issuer != "" {
+		options = append(options, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		options = append(options, jwt.WithAudience(m.audience))
+	}
+	return options
+}
+
+// ValidMethods lista os algoritmos aceitos, para jwt.WithValidMethods.
+func (m *Manager) ValidMethods() []string {
+	if m.hmacSecret != nil {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	seen := map[string]bool{}
+	var methods []string
+	for _, key := range m.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			methods = append(methods, key.Algorithm)
+		}
+	}
+	return methods
+}
+
+// JWK é a representação pública de uma chave (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Ed25519 (OKP)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS retorna todas as chaves de verificação. No modo HMAC a lista é
+// vazia: o segredo nunca é publicado.
+func (m *Manager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+
+	for _, key := range m.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func (m *Manager) findKey(kid string) *Key {
+	for i := range m.keys {
+		if m.keys[i].ID == kid {
+			return &m.keys[i]
+		}
+	}
+	return nil
+}
+
+// newKey define o algoritmo pelo tipo da chave e deriva o kid do hash
+// da chave pública — o mesmo arquivo gera sempre o mesmo kid, em
+// qualquer instância da API.
+func newKey(public crypto.PublicKey) (Key, error) {
+	var alg string
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return Key{}, fmt.Errorf("chave RSA com %d bits (mínimo %d)", pub.N.BitLen(), minRSABits)
+		}
+		alg = "RS256"
+	case ed25519.PublicKey:
+		alg = "EdDSA"
+	default:
+		return Key{}, fmt.Errorf("tipo de chave não suportado: %T (use RSA ou Ed25519)", public)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return Key{}, fmt.Errorf("erro ao serializar chave pública: %w", err)
+	}
+	sum := sha256.Sum256(der)
+
+	return Key{
+		ID:        hex.EncodeToString(sum[:8]),
+		Algorithm: alg,
+		public:    public,
+	}, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("arquivo %s não contém um bloco PEM", path)
+	}
+	return block, nil
+}
+
+func loadPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: bloco PEM %q não é uma chave privada", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao interpretar chave %s: %w", path, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: tipo de chave não suportado: %T", path, key)
+	}
+	return signer, nil
+}
+
+// loadPublicKey aceita também chaves privadas, para poder manter como
+// verificação o mesmo arquivo que antes era a chave de assinatura.
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao interpretar chave %s: %w", path, err)
+		}
+		return key, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao interpretar chave %s: %w", path, err)
+		}
+		return key, nil
+	default:
+		signer, err := loadPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return signer.Public(), nil
+	}
+}
@@ -0,0 +1,356 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "api"
+)
+
+func writeKey(t *testing.T, name string, key any) string {
+	t.Helper()
+
+	var block *pem.Block
+	switch k := key.(type) {
+	case crypto.Signer:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func load(t *testing.T, signing string, verification ...string) *Manager {
+	t.Helper()
+	m, err := LoadFromFiles(signing, verification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetIssuer(testIssuer, testAudience)
+	return m
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub": "42",
+		"iss": testIssuer,
+		"aud": testAudience,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	}
+}
+
+func parse(m *Manager, token string) error {
+	_, err := jwt.Parse(token, m.Keyfunc, m.ParserOptions()...)
+	return err
+}
+
+// publicFromJWK reconstrói a chave só a partir do JSON publicado, como
+// faria um consumidor do /.well-known/jwks.json.
+func publicFromJWK(t *testing.T, jwk JWK) any {
+	t.Helper()
+
+	decode := func(value string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			t.Fatalf("campo %q não é base64url sem padding: %v", value, err)
+		}
+		return b
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(jwk.N)),
+			E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64()),
+		}
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			t.Fatalf("crv inesperado: %s", jwk.Crv)
+		}
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+	t.Fatalf("kty inesperado: %s", jwk.Kty)
+	return nil
+}
+
+func TestSignVerifyWithPublishedJWKS(t *testing.T) {
+	tests := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+		kty  string
+	}{
+		{"RS256", newRSAKey(t, 2048), "RS256", "RSA"},
+		{"EdDSA", newEd25519Key(t), "EdDSA", "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := load(t, writeKey(t, "signing.pem", tt.key))
+
+			token, err := m.Sign(validClaims())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := parse(m, token); err != nil {
+				t.Fatalf("o próprio Manager deveria aceitar: %v", err)
+			}
+
+			raw, err := json.Marshal(m.JWKS())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var published JWKSet
+			if err := json.Unmarshal(raw, &published); err != nil {
+				t.Fatal(err)
+			}
+			if len(published.Keys) != 1 {
+				t.Fatalf("JWKS com %d chaves, want 1", len(published.Keys))
+			}
+			jwk := published.Keys[0]
+			if jwk.Alg != tt.alg || jwk.Kty != tt.kty || jwk.Use != "sig" {
+				t.Fatalf("JWK inesperado: %+v", jwk)
+			}
+
+			// Um consumidor externo verifica com a chave do JWKS, achada pelo kid.
+			parsed, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
+				if token.Header["kid"] != jwk.Kid {
+					t.Fatalf("kid do header %v, JWKS %s", token.Header["kid"], jwk.Kid)
+				}
+				return publicFromJWK(t, jwk), nil
+			}, jwt.WithValidMethods([]string{jwk.Alg}))
+			if err != nil || !parsed.Valid {
+				t.Fatalf("verificação com o JWKS publicado: %v", err)
+			}
+		})
+	}
+}
+
+func TestJWKSRSAExponent(t *testing.T) {
+	key := newRSAKey(t, 2048)
+	m := load(t, writeKey(t, "signing.pem", key))
+
+	// e = 65537 é "AQAB" em base64url sem zeros à esquerda (RFC 7518 §6.3.1.2).
+	if e := m.JWKS().Keys[0].E; e != "AQAB" {
+		t.Fatalf("e = %q, want AQAB", e)
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(m.JWKS().Keys[0].N)
+	if len(n) != 256 || n[0] == 0 {
+		t.Fatalf("n com %d bytes (primeiro %#x); want 256 sem zero à esquerda", len(n), n[0])
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldKey, newKey := newEd25519Key(t), newRSAKey(t, 2048)
+	oldPath := writeKey(t, "old.pem", oldKey)
+
+	before := load(t, oldPath)
+	oldToken, err := before.Sign(validClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A antiga vira verificação (como arquivo privado ou só a pública);
+	// repetir a mesma chave não duplica o JWKS.
+	after := load(t, writeKey(t, "new.pem", newKey), oldPath, writeKey(t, "old.pub", oldKey.Public()), " ")
+
+	if got := len(after.JWKS().Keys); got != 2 {
+		t.Fatalf("JWKS com %d chaves, want 2", got)
+	}
+	if err := parse(after, oldToken); err != nil {
+		t.Fatalf("token da chave antiga deveria valer durante a rotação: %v", err)
+	}
+
+	newToken, err := after.Sign(validClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if header.Header["alg"] != "RS256" || header.Header["kid"] != after.JWKS().Keys[0].Kid {
+		t.Fatalf("assinatura deveria usar a chave nova: %v", header.Header)
+	}
+	if err := parse(before, newToken); err == nil {
+		t.Fatal("Manager sem a chave nova não deveria aceitar o token dela")
+	}
+
+	// O kid vem da chave: outra instância com o mesmo arquivo gera o mesmo.
+	if again := load(t, oldPath); again.JWKS().Keys[0].Kid != before.JWKS().Keys[0].Kid {
+		t.Fatal("kid deveria ser estável entre carregamentos")
+	}
+}
+
+func TestKeyfuncRejects(t *testing.T) {
+	rsaKey := newRSAKey(t, 2048)
+	m := load(t, writeKey(t, "signing.pem", rsaKey))
+	kid := m.JWKS().Keys[0].Kid
+
+	sign := func(method jwt.SigningMethod, header map[string]any, claims jwt.MapClaims, key any) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, claims)
+		for name, value := range header {
+			token.Header[name] = value
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	other := newRSAKey(t, 2048)
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	noExp := validClaims()
+	delete(noExp, "exp")
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.example"
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "outra-api"
+
+	tests := map[string]string{
+		"kid desconhecido":           sign(jwt.SigningMethodRS256, map[string]any{"kid": "desconhecido"}, validClaims(), rsaKey),
+		"sem kid":                    sign(jwt.SigningMethodRS256, nil, validClaims(), rsaKey),
+		"kid certo, chave de outro":  sign(jwt.SigningMethodRS256, map[string]any{"kid": kid}, validClaims(), other),
+		"alg none":                   sign(jwt.SigningMethodNone, map[string]any{"kid": kid}, validClaims(), jwt.UnsafeAllowNoneSignatureType),
+		"HS256 com a chave pública":  sign(jwt.SigningMethodHS256, map[string]any{"kid": kid}, validClaims(), publicPEM),
+		"HS256 com o DER da pública": sign(jwt.SigningMethodHS256, map[string]any{"kid": kid}, validClaims(), publicDER),
+		"alg diferente do da chave":  sign(jwt.SigningMethodPS256, map[string]any{"kid": kid}, validClaims(), rsaKey),
+		"expirado":                   sign(jwt.SigningMethodRS256, map[string]any{"kid": kid}, expired, rsaKey),
+		"sem exp":                    sign(jwt.SigningMethodRS256, map[string]any{"kid": kid}, noExp, rsaKey),
+		"iss de outro emissor":       sign(jwt.SigningMethodRS256, map[string]any{"kid": kid}, wrongIssuer, rsaKey),
+		"aud de outra API":           sign(jwt.SigningMethodRS256, map[string]any{"kid": kid}, wrongAudience, rsaKey),
+	}
+
+	for name, token := range tests {
+		if err := parse(m, token); err == nil {
+			t.Errorf("%s: deveria ser recusado", name)
+		}
+	}
+
+	if err := parse(m, sign(jwt.SigningMethodRS256, map[string]any{"kid": kid}, validClaims(), rsaKey)); err != nil {
+		t.Fatalf("controle: token bem formado deveria passar: %v", err)
+	}
+}
+
+func TestHMACMode(t *testing.T) {
+	m := NewHMAC("segredo-de-teste")
+
+	if keys := m.JWKS().Keys; keys == nil || len(keys) != 0 {
+		t.Fatalf("modo HMAC não publica chaves: %v", keys)
+	}
+
+	token, err := m.Sign(validClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(m, token); err != nil {
+		t.Fatalf("HS256 com o segredo deveria passar: %v", err)
+	}
+
+	rsaManager := load(t, writeKey(t, "signing.pem", newRSAKey(t, 2048)))
+	rsaToken, err := rsaManager.Sign(validClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(m, rsaToken); err == nil {
+		t.Fatal("modo HMAC não deveria aceitar RS256")
+	}
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(m, none); err == nil {
+		t.Fatal("modo HMAC não deveria aceitar alg none")
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(t.TempDir(), "not.pem")
+	if err := os.WriteFile(notPEM, []byte("não é PEM"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	valid := writeKey(t, "valid.pem", newEd25519Key(t))
+
+	tests := map[string]struct {
+		signing      string
+		verification []string
+	}{
+		"RSA de 1024 bits":        {writeKey(t, "small.pem", newRSAKey(t, 1024)), nil},
+		"ECDSA":                   {writeKey(t, "ec.pem", ecKey), nil},
+		"arquivo sem PEM":         {notPEM, nil},
+		"arquivo inexistente":     {filepath.Join(t.TempDir(), "nada.pem"), nil},
+		"pública como assinatura": {writeKey(t, "pub.pem", ecKey.Public()), nil},
+		"verificação ECDSA":       {valid, []string{writeKey(t, "ec.pub", ecKey.Public())}},
+		"verificação RSA de 1024": {valid, []string{writeKey(t, "small.pub", newRSAKey(t, 1024).Public())}},
+		"verificação sem PEM":     {valid, []string{notPEM}},
+		"verificação inexistente": {valid, []string{filepath.Join(t.TempDir(), "nada.pem")}},
+	}
+
+	for name, tt := range tests {
+		if _, err := LoadFromFiles(tt.signing, tt.verification); err == nil {
+			t.Errorf("%s: deveria falhar", name)
+		}
+	}
+}
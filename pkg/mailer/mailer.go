@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"loginbackend/config"
+)
+
+// Message é um email de texto simples.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender é o contrato de envio de email. Os fluxos de autenticação
+// (reset de senha, verificação de email, ...) dependem só dele, então
+// trocar o provedor não exige mexer nas features.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// New escolhe a implementação a partir de MAIL_PROVIDER.
+func New(cfg *config.Config) (Sender, error) {
+	switch cfg.MailProvider {
+	case "", "log":
+		return &LogSender{From: cfg.MailFrom}, nil
+	case "file":
+		return &FileSender{From: cfg.MailFrom, Dir: cfg.MailDir}, nil
+	}
+
+	return nil, fmt.Errorf("provider de email desconhecido: %s", cfg.MailProvider)
+}
+
+// LogSender apenas imprime o email no log. Padrão para desenvolvimento:
+// o link de reset/verificação aparece direto no terminal da API.
+type LogSender struct {
+	From string
+}
+
+func (s *LogSender) Send(msg Message) error {
+	log.Printf("📧 Email para %s | De: %s | Assunto: %s\n%s", msg.To, s.From, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender grava cada email como um arquivo .eml no diretório
+// configurado (útil para testes automatizados e inspeção manual).
+type FileSender struct {
+	From string
+	Dir  string
+}
+
+func (s *FileSender) Send(msg Message) error {
+	if err := os.MkdirAll(s.Dir, os.ModePerm); err != nil {
+		return fmt.Errorf("erro ao criar diretório de emails: %w", err)
+	}
+
+	// Nome ordenável por data + destinatário, sem caracteres problemáticos
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	filename := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), recipient)
+
+	content := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.From, msg.To, msg.Subject, time.Now().Format(time.RFC1123Z), msg.Body,
+	)
+
+	if err := os.WriteFile(filepath.Join(s.Dir, filename), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("erro ao gravar email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIssuer é um provedor OIDC local: discovery e JWKS com uma chave RSA.
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	issuer string // issuer anunciado no discovery (padrão: URL do servidor)
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 m.issuer,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	m.issuer = m.server.URL
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) provider() *Provider {
+	return NewProvider(Config{Name: "mock", Issuer: m.server.URL, ClientID: "client-1"})
+}
+
+// sign emite um id_token válido para client-1 com nonce "n1"; mutate
+// altera os claims antes da assinatura.
+func (m *mockIssuer) sign(t *testing.T, mutate func(jwt.MapClaims)) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            "client-1",
+		"sub":            "user-123",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          "n1",
+		"email":          " Alice@Example.com ",
+		"email_verified": true,
+		"name":           "Alice",
+	}
+	if mutate != nil {
+		mutate(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	m := newMockIssuer(t)
+
+	claims, err := m.provider().VerifyIDToken(context.Background(), m.sign(t, nil), "n1")
+	if err != nil {
+		t.Fatalf("id_token válido recusado: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "alice@example.com" || !claims.EmailVerified || claims.Name != "Alice" {
+		t.Fatalf("claims inesperados: %+v", claims)
+	}
+}
+
+func TestVerifyIDTokenEmailVerifiedString(t *testing.T) {
+	m := newMockIssuer(t)
+
+	tests := []struct {
+		value any
+		want  bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1", false},
+		{false, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		raw := m.sign(t, func(c jwt.MapClaims) { c["email_verified"] = tt.value })
+		claims, err := m.provider().VerifyIDToken(context.Background(), raw, "n1")
+		if err != nil {
+			t.Fatalf("email_verified=%v: %v", tt.value, err)
+		}
+		if claims.EmailVerified != tt.want {
+			t.Errorf("email_verified=%#v: got %v, want %v", tt.value, claims.EmailVerified, tt.want)
+		}
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	m := newMockIssuer(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token func() string
+		nonce string
+	}{
+		{"nonce diferente", func() string { return m.sign(t, nil) }, "outro"},
+		{"nonce ausente", func() string { return m.sign(t, func(c jwt.MapClaims) { delete(c, "nonce") }) }, ""},
+		{"iss diferente", func() string { return m.sign(t, func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }) }, "n1"},
+		{"aud diferente", func() string { return m.sign(t, func(c jwt.MapClaims) { c["aud"] = "client-2" }) }, "n1"},
+		{"expirado", func() string {
+			return m.sign(t, func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })
+		}, "n1"},
+		{"sem exp", func() string { return m.sign(t, func(c jwt.MapClaims) { delete(c, "exp") }) }, "n1"},
+		{"sem sub", func() string { return m.sign(t, func(c jwt.MapClaims) { delete(c, "sub") }) }, "n1"},
+		{"assinado por outra chave", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+				"iss": m.server.URL, "aud": "client-1", "sub": "user-123",
+				"exp": time.Now().Add(time.Minute).Unix(), "nonce": "n1",
+			})
+			token.Header["kid"] = "k1"
+			raw, _ := token.SignedString(other)
+			return raw
+		}, "n1"},
+		{"alg none", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+				"iss": m.server.URL, "aud": "client-1", "sub": "user-123",
+				"exp": time.Now().Add(time.Minute).Unix(), "nonce": "n1",
+			})
+			raw, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return raw
+		}, "n1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.provider().VerifyIDToken(context.Background(), tt.token(), tt.nonce); err == nil {
+				t.Fatal("id_token deveria ter sido recusado")
+			}
+		})
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	m := newMockIssuer(t)
+	m.issuer = "https://evil.example"
+
+	_, err := m.provider().VerifyIDToken(context.Background(), m.sign(t, nil), "n1")
+	if err == nil || !strings.Contains(err.Error(), "issuer") {
+		t.Fatalf("discovery com issuer diferente deveria falhar, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// rangePrefixLength é o tamanho do prefixo do SHA-1 usado como faixa, o
+// mesmo do modelo k-anonymity do Pwned Passwords.
+const rangePrefixLength = 5
+
+// BreachedList é uma cópia local de hashes SHA-1 de senhas vazadas,
+// indexada por faixa (os 5 primeiros caracteres do hash). A consulta
+// segue o modelo k-anonymity: busca-se a faixa do prefixo e compara-se
+// o sufixo dentro dela, sem a senha nem o hash completo sair do processo.
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+	size   int
+}
+
+// LoadBreachedList lê um arquivo no formato do Pwned Passwords: um hash
+// SHA-1 em hex por linha, opcionalmente seguido de ":contagem". Linhas
+// vazias ou começando com # são ignoradas.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir lista de senhas vazadas: %w", err)
+	}
+	defer file.Close()
+
+	list := &BreachedList{ranges: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(entry, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("lista de senhas vazadas: hash inválido na linha %d", line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("lista de senhas vazadas: hash inválido na linha %d", line)
+		}
+
+		list.add(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler lista de senhas vazadas: %w", err)
+	}
+
+	return list, nil
+}
+
+func (l *BreachedList) add(hash string) {
+	prefix, suffix := hash[:rangePrefixLength], hash[rangePrefixLength:]
+
+	bucket, ok := l.ranges[prefix]
+	if !ok {
+		bucket = make(map[string]struct{})
+		l.ranges[prefix] = bucket
+	}
+	if _, exists := bucket[suffix]; !exists {
+		bucket[suffix] = struct{}{}
+		l.size++
+	}
+}
+
+// Len é o número de hashes carregados.
+func (l *BreachedList) Len() int {
+	return l.size
+}
+
+// Contains indica se a senha está na lista.
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	bucket, ok := l.ranges[hash[:rangePrefixLength]]
+	if !ok {
+		return false
+	}
+	_, found := bucket[hash[rangePrefixLength:]]
+	return found
+}
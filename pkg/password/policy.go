@@ -0,0 +1,134 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"loginbackend/config"
+	"loginbackend/pkg/utils"
+)
+
+// Policy reúne as regras de senha aplicadas em todo lugar onde uma senha
+// nova é definida (cadastro, troca e redefinição). Os fluxos dependem só
+// dela, então endurecer a política é só mudar a configuração.
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// MaxAge é a validade da senha a partir de last_password_update
+	// (zero desabilita a expiração).
+	MaxAge time.Duration
+	// HistorySize é quantas das últimas senhas (a atual inclusive) não
+	// podem ser reutilizadas (zero desabilita a checagem).
+	HistorySize int
+
+	// Breached é a lista local de senhas vazadas (nil desabilita).
+	Breached *BreachedList
+}
+
+// New monta a política a partir das variáveis PASSWORD_*. Uma lista de
+// senhas vazadas configurada mas ilegível é erro: melhor falhar no deploy
+// do que aceitar senhas vazadas achando que estão sendo barradas.
+func New(cfg *config.Config) (*Policy, error) {
+	policy := &Policy{
+		MinLength:     cfg.PasswordMinLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		MaxAge:        time.Duration(cfg.PasswordMaxAgeDays) * 24 * time.Hour,
+		HistorySize:   cfg.PasswordHistorySize,
+	}
+
+	if cfg.PasswordBreachedList != "" {
+		breached, err := LoadBreachedList(cfg.PasswordBreachedList)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = breached
+	}
+
+	return policy, nil
+}
+
+// Validate confere tamanho, classes de caracteres e a lista de vazadas.
+// A mensagem lista tudo o que falta, para o usuário corrigir de uma vez.
+func (p *Policy) Validate(password string) error {
+	var missing []string
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		missing = append(missing, fmt.Sprintf("ao menos %d caracteres", p.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		missing = append(missing, "uma letra maiúscula")
+	}
+	if p.RequireLower && !lower {
+		missing = append(missing, "uma letra minúscula")
+	}
+	if p.RequireDigit && !digit {
+		missing = append(missing, "um número")
+	}
+	if p.RequireSymbol && !symbol {
+		missing = append(missing, "um símbolo")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("a senha precisa ter %s", strings.Join(missing, ", "))
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		return errors.New("esta senha aparece em vazamentos conhecidos. Escolha outra")
+	}
+
+	return nil
+}
+
+// CheckReuse recusa a senha se ela bater com o hash atual ou com algum
+// dos anteriores (previous vem do mais recente para o mais antigo).
+func (p *Policy) CheckReuse(password, currentHash string, previous []string) error {
+	if p.HistorySize <= 0 {
+		return nil
+	}
+
+	hashes := append([]string{currentHash}, previous...)
+	if len(hashes) > p.HistorySize {
+		hashes = hashes[:p.HistorySize]
+	}
+
+	for _, hash := range hashes {
+		if hash != "" && utils.CheckPassword(password, hash) {
+			return fmt.Errorf("a senha não pode repetir nenhuma das últimas %d", p.HistorySize)
+		}
+	}
+	return nil
+}
+
+// Expired indica se a senha trocada em lastUpdate já passou do MaxAge.
+func (p *Policy) Expired(lastUpdate time.Time) bool {
+	if p.MaxAge <= 0 || lastUpdate.IsZero() {
+		return false
+	}
+	return time.Since(lastUpdate) > p.MaxAge
+}
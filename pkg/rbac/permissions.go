@@ -0,0 +1,76 @@
+package rbac
+
+import "sort"
+
+// Permissões de sistema (o que um papel pode fazer na administração).
+// Não confundir com pkg/acl, que controla o acesso a cada recurso.
+// Os papéis são dados (tabela roles/role_permissions); só o catálogo
+// abaixo vive no código, porque cada permissão é checada em uma rota.
+const (
+	// PermissionAll concede tudo, inclusive permissões criadas depois.
+	// É a permissão do SUPER_ADMIN.
+	PermissionAll = "*"
+
+	UsersList        = "users:list"
+	UsersManage      = "users:manage"
+	UsersUpdateRole  = "users:update_role"
+	UsersUnlock      = "users:unlock"
+	UsersImpersonate = "users:impersonate"
+	UsersInvite      = "users:invite"
+
+	RolesManage = "roles:manage"
+
+	OAuthClientsManage = "oauth_clients:manage"
+
+	ACLAdmin = "acl:admin"
+)
+
+// Catalog descreve as permissões aceitas em role_permissions.
+var Catalog = map[string]string{
+	PermissionAll:      "Acesso total (inclusive permissões futuras)",
+	UsersList:          "Listar todos os usuários",
+	UsersManage:        "Editar, desativar e gerenciar a conta de outros usuários",
+	UsersUpdateRole:    "Alterar o papel de um usuário",
+	UsersUnlock:        "Desbloquear contas bloqueadas por tentativas de login",
+	UsersImpersonate:   "Entrar como outro usuário (suporte)",
+	UsersInvite:        "Convidar pessoas para criar conta (qualquer time ou papel atribuível)",
+	RolesManage:        "Criar e editar papéis e suas permissões",
+	OAuthClientsManage: "Cadastrar e remover clientes OAuth",
+	ACLAdmin:           "Acessar qualquer recurso, ignorando as ACLs",
+}
+
+// Valid indica se a permissão existe no catálogo.
+func Valid(permission string) bool {
+	_, ok := Catalog[permission]
+	return ok
+}
+
+// Set é o conjunto de permissões de um papel.
+type Set map[string]struct{}
+
+func NewSet(permissions ...string) Set {
+	set := make(Set, len(permissions))
+	for _, permission := range permissions {
+		set[permission] = struct{}{}
+	}
+	return set
+}
+
+// Has considera o curinga PermissionAll.
+func (s Set) Has(permission string) bool {
+	if _, ok := s[PermissionAll]; ok {
+		return true
+	}
+	_, ok := s[permission]
+	return ok
+}
+
+// List devolve as permissões em ordem alfabética.
+func (s Set) List() []string {
+	list := make([]string, 0, len(s))
+	for permission := range s {
+		list = append(list, permission)
+	}
+	sort.Strings(list)
+	return list
+}
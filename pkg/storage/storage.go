@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"loginbackend/config"
+)
+
+var (
+	// ErrNotFound é devolvido por Get quando a chave não existe.
+	ErrNotFound = errors.New("arquivo não encontrado")
+	// ErrInvalidKey é devolvido para chaves vazias ou de "diretório".
+	ErrInvalidKey = errors.New("chave de arquivo inválida")
+)
+
+// ValidKey diz se a chave aponta para um arquivo: vazia ou terminada em
+// "/" seria a raiz do bucket (listagem) ou um diretório.
+func ValidKey(key string) bool {
+	key = strings.TrimLeft(key, "/")
+	return key != "" && !strings.HasSuffix(key, "/")
+}
+
+// ObjectInfo são os metadados devolvidos junto com o conteúdo.
+type ObjectInfo struct {
+	ContentType string
+	Size        int64
+}
+
+// Storage é o contrato dos uploads. As chaves são caminhos relativos
+// ("42-ab12...-256.jpg"); cada driver decide onde gravá-las. As features
+// dependem só dele, então trocar de disco local para S3 é configuração.
+type Storage interface {
+	Put(key string, body io.Reader, contentType string) error
+	// Get devolve o conteúdo (quem chama fecha) ou ErrNotFound.
+	Get(key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete não falha se a chave não existir.
+	Delete(key string) error
+	// List devolve as chaves que começam com prefix.
+	List(prefix string) ([]string, error)
+	// SignedURL dá acesso temporário a um arquivo privado.
+	SignedURL(key string, expiry time.Duration) (string, error)
+	// URL é o endereço público do arquivo (STORAGE_URL + chave).
+	URL(key string) string
+}
+
+// New escolhe o driver a partir de UPLOAD_PROVIDER.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.UploadProvider {
+	case "", "local":
+		return NewLocal(cfg.UploadDir, cfg.StorageURL), nil
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			ForcePathStyle:  cfg.S3ForcePathStyle,
+			BaseURL:         cfg.StorageURL,
+		})
+	}
+
+	return nil, fmt.Errorf("provider de upload desconhecido: %s", cfg.UploadProvider)
+}
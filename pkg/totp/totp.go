@@ -0,0 +1,95 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parâmetros RFC 6238 usados pela maioria dos apps autenticadores
+// (Google Authenticator, Authy, 1Password). Não mudar sem migrar os
+// segredos já cadastrados — o app do usuário não saberia do novo valor.
+const (
+	Digits    = 6
+	Period    = 30 * time.Second
+	secretLen = 20 // 160 bits, recomendado pela RFC 4226 para HMAC-SHA1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret gera um segredo aleatório em base32 (sem padding),
+// pronto para ser salvo em users.totp_secret e exibido no QR code.
+func GenerateSecret() (string, error) {
+	bytes := make([]byte, secretLen)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("erro ao gerar segredo TOTP: %w", err)
+	}
+	return encoding.EncodeToString(bytes), nil
+}
+
+// URI monta a URI otpauth:// que os apps autenticadores leem via QR code.
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", Digits))
+	params.Set("period", fmt.Sprintf("%d", int(Period.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Validate verifica o código contra o segredo aceitando uma janela de
+// ±1 passo (tolerância a relógio dessincronizado do celular).
+// Retorna o passo que casou, para o chamador impedir replay do mesmo código.
+func Validate(code, secret string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(Period.Seconds())
+	for _, step := range []int64{current - 1, current, current + 1} {
+		expected := generate(key, step)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// generate implementa o HOTP (RFC 4226) para um contador específico.
+func generate(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
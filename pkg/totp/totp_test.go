@@ -0,0 +1,130 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Segredo dos apêndices das RFCs 4226 e 6238 (HMAC-SHA1): "12345678901234567890".
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+// RFC 4226, Apêndice D: HOTP para os contadores 0..9.
+func TestGenerateRFC4226(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, code := range want {
+		if got := generate([]byte("12345678901234567890"), int64(counter)); got != code {
+			t.Errorf("contador %d: got %s, want %s", counter, got, code)
+		}
+	}
+}
+
+// RFC 6238, Apêndice B (SHA1). Os vetores têm 8 dígitos; com Digits = 6
+// o código são os 6 últimos.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+func TestValidateRFC6238(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		now := time.Unix(tt.unix, 0)
+		code := tt.code[len(tt.code)-Digits:]
+
+		step, ok := Validate(code, rfcSecret, now)
+		if !ok {
+			t.Errorf("T=%d: código %s deveria valer", tt.unix, code)
+			continue
+		}
+		if want := tt.unix / int64(Period.Seconds()); step != want {
+			t.Errorf("T=%d: passo %d, want %d", tt.unix, step, want)
+		}
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	// T=1111111111 está no passo 37037037; o código vale um passo antes e
+	// um depois (relógio do celular adiantado/atrasado), e não além.
+	const code = "050471"
+	base := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		offset time.Duration
+		valid  bool
+	}{
+		{-2 * Period, false},
+		{-Period, true},
+		{0, true},
+		{Period, true},
+		{2 * Period, false},
+	}
+
+	for _, tt := range tests {
+		step, ok := Validate(code, rfcSecret, base.Add(tt.offset))
+		if ok != tt.valid {
+			t.Errorf("deslocamento %s: got %v, want %v", tt.offset, ok, tt.valid)
+		}
+		if ok && step != 37037037 {
+			t.Errorf("deslocamento %s: passo %d, want 37037037 (o passo do código, para o anti-replay)", tt.offset, step)
+		}
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := map[string]struct{ code, secret string }{
+		"código errado":    {"000000", rfcSecret},
+		"8 dígitos":        {"94287082", rfcSecret},
+		"5 dígitos":        {"87082", rfcSecret},
+		"vazio":            {"", rfcSecret},
+		"segredo inválido": {"287082", "não-é-base32!"},
+		"segredo de outro": {"287082", encoding.EncodeToString([]byte("outro segredo qualquer"))},
+		"não numérico":     {"28708a", rfcSecret},
+		"espaço no meio":   {"287 082", rfcSecret},
+	}
+
+	for name, tt := range tests {
+		if _, ok := Validate(tt.code, tt.secret, now); ok {
+			t.Errorf("%s: deveria ser recusado", name)
+		}
+	}
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	if _, ok := Validate(" 287082\n", rfcSecret, now); !ok {
+		t.Error("espaços nas pontas deveriam ser ignorados")
+	}
+	if _, ok := Validate("287082", strings.ToLower(rfcSecret), now); !ok {
+		t.Error("segredo em minúsculas deveria ser aceito")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != secretLen {
+		t.Fatalf("segredo %q: %d bytes, err %v", secret, len(key), err)
+	}
+
+	now := time.Now()
+	code := generate(key, now.Unix()/int64(Period.Seconds()))
+	if _, ok := Validate(code, secret, now); !ok {
+		t.Fatal("código gerado com o segredo novo deveria valer")
+	}
+}
@@ -0,0 +1,203 @@
+package uploader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/png" // registra o decoder de PNG para image.Decode
+)
+
+// Limites contra "decompression bombs": um PNG de poucos KB pode declarar
+// dimensões que ocupariam gigabytes depois de decodificado. As dimensões
+// são conferidas pelo cabeçalho, antes de decodificar a imagem.
+const (
+	maxAvatarDimension = 6000
+	maxAvatarPixels    = 24_000_000
+)
+
+// ErrInvalidImage marca erros do arquivo enviado (formato, dimensões),
+// em oposição a falhas de gravação.
+var ErrInvalidImage = errors.New("imagem inválida")
+
+// decodeAvatar valida formato e dimensões, decodifica e aplica a
+// orientação EXIF (fotos de celular). O resultado é RGBA sem metadados.
+func decodeAvatar(data []byte) (*image.RGBA, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: erro ao decodificar imagem: %v", ErrInvalidImage, err)
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, fmt.Errorf("%w: formato não suportado: %s (use jpg ou png)", ErrInvalidImage, format)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 ||
+		cfg.Width > maxAvatarDimension || cfg.Height > maxAvatarDimension ||
+		cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, fmt.Errorf("%w: imagem muito grande: %dx%d (máximo %dx%d)", ErrInvalidImage, cfg.Width, cfg.Height, maxAvatarDimension, maxAvatarDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: erro ao decodificar imagem: %v", ErrInvalidImage, err)
+	}
+
+	// Fundo branco: o JPEG não tem transparência (PNG transparente ficaria preto)
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Over)
+
+	if format == "jpeg" {
+		rgba = orient(rgba, jpegOrientation(data))
+	}
+	return rgba, nil
+}
+
+// squareCrop recorta o maior quadrado central.
+func squareCrop(img *image.RGBA) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	side := min(w, h)
+	x0, y0 := (w-side)/2, (h-side)/2
+	return img.SubImage(image.Rect(x0, y0, x0+side, y0+side)).(*image.RGBA)
+}
+
+// resize redimensiona para size x size pela média da área de origem de
+// cada pixel (boa qualidade para reduzir; ao ampliar, vira vizinho mais
+// próximo). Espera uma imagem quadrada.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		sy0 := y * sh / size
+		sy1 := max(sy0+1, (y+1)*sh/size)
+		for x := 0; x < size; x++ {
+			sx0 := x * sw / size
+			sx1 := max(sx0+1, (x+1)*sw/size)
+
+			var r, g, bl, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				i := src.PixOffset(b.Min.X+sx0, b.Min.Y+sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					bl += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(bl / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// orient aplica a orientação EXIF (1-8): a foto passa a ser gravada já
+// "em pé", já que o EXIF não vai para as variantes.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for dy := 0; dy < dh; dy++ {
+		for dx := 0; dx < dw; dx++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // espelhada na horizontal
+				sx, sy = w-1-dx, dy
+			case 3: // 180°
+				sx, sy = w-1-dx, h-1-dy
+			case 4: // espelhada na vertical
+				sx, sy = dx, h-1-dy
+			case 5: // transposta
+				sx, sy = dy, dx
+			case 6: // 90° horário
+				sx, sy = dy, h-1-dx
+			case 7: // transversa
+				sx, sy = w-1-dy, h-1-dx
+			case 8: // 90° anti-horário
+				sx, sy = w-1-dy, dx
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation lê a tag Orientation (0x0112) do EXIF de um JPEG.
+// Devolve 1 (normal) se não houver EXIF ou se ele estiver malformado.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // início da imagem em si
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
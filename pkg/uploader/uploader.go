@@ -1,66 +1,111 @@
 package uploader
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"image"
 	"image/jpeg"
-	"mime/multipart"
-	"os"
-	"path/filepath"
+	"io"
+	"regexp"
+	"strconv"
 	"strings"
 
-	"loginbackend/config"
+	"loginbackend/pkg/storage"
 )
 
-// UploadProfilePicture recebe o ID do usuário para criar um nome de arquivo fixo (Canonical Name)
-func UploadProfilePicture(userID string, file multipart.File, header *multipart.FileHeader, cfg *config.Config) (string, error) {
-	// 1. Decodificar a imagem (detecta se é PNG ou JPEG)
-	img, format, err := image.Decode(file)
+// AvatarSizes são os lados (px) das variantes quadradas geradas no upload.
+var AvatarSizes = []int{64, 256, 512}
+
+// DefaultAvatarSize é a variante gravada em avatar_url (clientes antigos).
+const DefaultAvatarSize = 256
+
+// Avatar é o resultado do upload: Hash identifica o conteúdo (vai no nome
+// dos arquivos, então uma foto nova sempre tem URL nova) e Variants traz
+// a URL de cada tamanho ("64", "256", "512").
+type Avatar struct {
+	Hash     string
+	Variants map[string]string
+}
+
+// URL devolve a URL da variante padrão.
+func (a *Avatar) URL() string {
+	return a.Variants[strconv.Itoa(DefaultAvatarSize)]
+}
+
+// UploadAvatar processa a imagem enviada: valida dimensões, corrige a
+// orientação, recorta o quadrado central e grava um JPEG por tamanho em
+// {userID}-{hash}-{tamanho}.jpg. Re-encodar descarta o EXIF (GPS, modelo
+// da câmera, ...). Os arquivos anteriores continuam lá até PruneAvatars.
+func UploadAvatar(store storage.Storage, userID string, file io.Reader) (*Avatar, error) {
+	data, err := io.ReadAll(file)
 	if err != nil {
-		return "", fmt.Errorf("erro ao decodificar imagem: %v", err)
+		return nil, fmt.Errorf("erro ao ler imagem: %v", err)
 	}
 
-	// Validação básica de formato
-	if format != "jpeg" && format != "png" {
-		return "", fmt.Errorf("formato não suportado: %s (use jpg ou png)", format)
+	img, err := decodeAvatar(data)
+	if err != nil {
+		return nil, err
 	}
+	square := squareCrop(img)
 
-	// 2. Definir nome CANÔNICO (Sempre userID.jpg)
-	filename := fmt.Sprintf("%s.jpg", userID)
-
-	if cfg.UploadProvider == "local" {
-		return saveLocalFixed(img, filename, cfg)
+	sum := sha256.Sum256(data)
+	avatar := &Avatar{
+		Hash:     hex.EncodeToString(sum[:8]),
+		Variants: make(map[string]string, len(AvatarSizes)),
 	}
 
-	return "", fmt.Errorf("provider desconhecido")
-}
+	for _, size := range AvatarSizes {
+		// Quality 85 é um excelente balanço entre tamanho e qualidade
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, fmt.Errorf("erro ao converter para jpg: %v", err)
+		}
 
-func saveLocalFixed(img image.Image, filename string, cfg *config.Config) (string, error) {
-	// Garantir diretório
-	if _, err := os.Stat(cfg.UploadDir); os.IsNotExist(err) {
-		os.MkdirAll(cfg.UploadDir, os.ModePerm)
+		key := fmt.Sprintf("%s-%s-%d.jpg", userID, avatar.Hash, size)
+		if err := store.Put(key, &buf, "image/jpeg"); err != nil {
+			return nil, fmt.Errorf("erro ao gravar avatar: %w", err)
+		}
+		avatar.Variants[strconv.Itoa(size)] = store.URL(key)
 	}
 
-	filePath := filepath.Join(cfg.UploadDir, filename)
+	return avatar, nil
+}
 
-	// 3. Criar/Sobrescrever o arquivo
-	dst, err := os.Create(filePath)
+// PruneAvatars apaga os avatares do usuário que não são do conteúdo
+// keepHash, inclusive o antigo {userID}.jpg. Chamado depois que o banco
+// já aponta para o avatar novo.
+func PruneAvatars(store storage.Storage, userID, keepHash string) error {
+	keys, err := store.List(userID + "-")
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer dst.Close()
+	keys = append(keys, userID+".jpg")
 
-	// 4. Re-encodar tudo para JPEG (Padronização e Compressão)
-	// Quality 85 é um excelente balanço entre tamanho e qualidade
-	opts := &jpeg.Options{Quality: 85}
-	if err := jpeg.Encode(dst, img, opts); err != nil {
-		return "", fmt.Errorf("erro ao converter para jpg: %v", err)
+	keepPrefix := fmt.Sprintf("%s-%s-", userID, keepHash)
+	for _, key := range keys {
+		if keepHash != "" && strings.HasPrefix(key, keepPrefix) {
+			continue
+		}
+		if err := store.Delete(key); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// DeleteAvatar apaga todos os arquivos de avatar do usuário.
+// Arquivo inexistente não é erro.
+func DeleteAvatar(store storage.Storage, userID string) error {
+	return PruneAvatars(store, userID, "")
+}
+
+// versionedName reconhece os nomes com hash do conteúdo gerados por
+// UploadAvatar ({userID}-{hash}-{tamanho}.jpg).
+var versionedName = regexp.MustCompile(`^\d+-[0-9a-f]{16}-\d+\.jpg$`)
 
-	// Retorna URL.
-	// Nota: Adicionamos um timestamp fictício aqui se quisermos forçar o refresh no retorno imediato,
-	// mas o ideal é o frontend gerenciar o cache busting.
-	baseURL := strings.TrimRight(cfg.StorageURL, "/")
-	fullURL := fmt.Sprintf("%s/%s", baseURL, filename)
-	return fullURL, nil
+// IsVersioned indica se o conteúdo da chave nunca muda (o nome muda junto),
+// o que permite cache "immutable" ao servir o arquivo.
+func IsVersioned(key string) bool {
+	return versionedName.MatchString(key)
 }
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -33,21 +32,6 @@ func GenerateSnowflakeID() string {
 	return node.Generate().String()
 }
 
-// HashPassword gera um hash bcrypt da senha
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("erro ao gerar hash da senha: %w", err)
-	}
-	return string(bytes), nil
-}
-
-// CheckPasswordHash compara uma senha com seu hash
-func CheckPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
-
 // ParseTime converte uma string para time.Time
 // Suporta formatos ISO, PostgreSQL e datas simples.
 // Sempre retorna o tempo em UTC.
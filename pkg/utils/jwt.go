@@ -3,10 +3,13 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
+	"loginbackend/pkg/jwtkeys"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -14,19 +17,65 @@ type TokenClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	RoleID int    `json:"role_id"`
+	// SessionID liga o access token à sessão (família de refresh tokens)
+	// que o emitiu, permitindo revogar todos os access tokens da sessão.
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion é a época do usuário na emissão; tokens de uma época
+	// anterior são recusados pelo AuthMiddleware.
+	TokenVersion int `json:"tv"`
+	// ClientID/Scope identificam tokens emitidos pelo servidor OAuth. Em
+	// tokens client_credentials não há usuário: o sub é o próprio cliente.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// PersonalTokenID só existe em claims montados a partir de um
+	// personal access token (nunca viaja em um JWT).
+	PersonalTokenID string `json:"-"`
+	// Act (RFC 8693) identifica o administrador quando o token foi
+	// emitido por personificação: UserID é o usuário personificado.
+	Act *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(claims TokenClaims, secret string, expiry time.Duration) (string, error) {
-	claims.RegisteredClaims = jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		NotBefore: jwt.NewNumericDate(time.Now()),
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
+// ImpersonatorID é o admin por trás do token, ou "" se não há personificação.
+func (c *TokenClaims) ImpersonatorID() string {
+	if c.Act == nil {
+		return ""
 	}
+	return c.Act.Subject
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// subject é o usuário, ou o cliente OAuth quando não há usuário.
+func (c TokenClaims) subject() string {
+	if c.UserID != "" {
+		return c.UserID
+	}
+	return c.ClientID
+}
 
-	tokenString, err := token.SignedString([]byte(secret))
+func GenerateJWT(claims TokenClaims, keys *jwtkeys.Manager, expiry time.Duration) (string, error) {
+	jti, err := generateTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    keys.Issuer(),
+		Subject:   claims.subject(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ID:        jti,
+	}
+	if audience := keys.Audience(); audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
+
+	tokenString, err := keys.Sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("erro ao assinar token: %w", err)
 	}
@@ -34,25 +83,36 @@ func GenerateJWT(claims TokenClaims, secret string, expiry time.Duration) (strin
 	return tokenString, nil
 }
 
-func ValidateJWT(tokenString, secret string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("método de assinatura inválido: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+func ValidateJWT(tokenString string, keys *jwtkeys.Manager) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, keys.Keyfunc, keys.ParserOptions()...)
 
 	if err != nil {
 		return nil, fmt.Errorf("erro ao validar token: %w", err)
 	}
 
 	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid {
+		// Sem jti o token não pode ser revogado individualmente (blacklist).
+		if claims.ID == "" {
+			return nil, errors.New("token sem jti")
+		}
+		if claims.Subject != claims.subject() {
+			return nil, errors.New("sub não confere com user_id")
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("token inválido")
 }
 
+// generateTokenID gera o jti: 128 bits aleatórios em hex.
+func generateTokenID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("erro ao gerar jti: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
 func GenerateRefreshToken() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
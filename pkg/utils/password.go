@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algoritmos aceitos em PasswordHashing.Algorithm.
+const (
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmBcrypt   = "bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+
+	// Limites para os parâmetros lidos de um hash salvo: um valor adulterado
+	// no banco não pode fazer o login alocar terabytes nem travar a CPU.
+	argon2MaxMemory = 1024 * 1024 // 1 GiB
+	argon2MaxTime   = 16
+)
+
+// PasswordHashing define como as senhas novas são gravadas. Hashes já
+// salvos continuam sendo aceitos em qualquer formato conhecido (bcrypt
+// "$2a$..." ou argon2id no formato PHC "$argon2id$v=19$m=...,t=...,p=...$salt$hash");
+// os que não batem com a configuração atual são refeitos no próximo login.
+type PasswordHashing struct {
+	Algorithm  string
+	BcryptCost int
+
+	// Argon2Memory em KiB; Argon2Time é o número de passadas.
+	Argon2Memory  uint32
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+// DefaultPasswordHashing segue a recomendação da OWASP para argon2id.
+var DefaultPasswordHashing = PasswordHashing{
+	Algorithm:     HashAlgorithmArgon2id,
+	BcryptCost:    bcrypt.DefaultCost,
+	Argon2Memory:  64 * 1024,
+	Argon2Time:    3,
+	Argon2Threads: 2,
+}
+
+var (
+	passwordHashing   = DefaultPasswordHashing
+	passwordHashingMu sync.RWMutex
+)
+
+// SetPasswordHashing troca a configuração usada por HashPassword e
+// NeedsRehash. Chamado uma vez na inicialização.
+func SetPasswordHashing(cfg PasswordHashing) error {
+	switch cfg.Algorithm {
+	case HashAlgorithmArgon2id:
+		if cfg.Argon2Memory == 0 || cfg.Argon2Time == 0 || cfg.Argon2Threads == 0 {
+			return errors.New("parâmetros do argon2id inválidos")
+		}
+		if cfg.Argon2Memory > argon2MaxMemory || cfg.Argon2Time > argon2MaxTime {
+			return fmt.Errorf("argon2id aceita no máximo %d KiB de memória e %d passadas", argon2MaxMemory, argon2MaxTime)
+		}
+	case HashAlgorithmBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("custo do bcrypt deve estar entre %d e %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return fmt.Errorf("algoritmo de hash de senha desconhecido: %s", cfg.Algorithm)
+	}
+
+	passwordHashingMu.Lock()
+	passwordHashing = cfg
+	passwordHashingMu.Unlock()
+	return nil
+}
+
+func currentPasswordHashing() PasswordHashing {
+	passwordHashingMu.RLock()
+	defer passwordHashingMu.RUnlock()
+	return passwordHashing
+}
+
+// HashPassword gera o hash da senha com o algoritmo configurado.
+func HashPassword(password string) (string, error) {
+	cfg := currentPasswordHashing()
+
+	if cfg.Algorithm == HashAlgorithmBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), cfg.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("erro ao gerar hash da senha: %w", err)
+		}
+		return string(bytes), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, cfg.Argon2Time, cfg.Argon2Memory, cfg.Argon2Threads, argon2KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, cfg.Argon2Memory, cfg.Argon2Time, cfg.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CheckPassword compara uma senha com o hash salvo, em qualquer formato
+// suportado. Hash vazio ou desconhecido nunca confere.
+func CheckPassword(password, hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(computed, key) == 1
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+// NeedsRehash indica se o hash usa outro algoritmo ou parâmetros
+// diferentes da configuração atual. Só faz sentido chamar depois de um
+// CheckPassword bem-sucedido, quando a senha em texto está disponível.
+func NeedsRehash(hash string) bool {
+	cfg := currentPasswordHashing()
+
+	if strings.HasPrefix(hash, "$argon2id$") {
+		if cfg.Algorithm != HashAlgorithmArgon2id {
+			return true
+		}
+		params, _, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return true
+		}
+		return params.memory != cfg.Argon2Memory ||
+			params.time != cfg.Argon2Time ||
+			params.threads != cfg.Argon2Threads ||
+			len(key) != argon2KeyLength
+	}
+
+	if cfg.Algorithm != HashAlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != cfg.BcryptCost
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func parseArgon2Hash(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("hash argon2id malformado")
+	}
+
+	if parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("hash argon2id malformado")
+	}
+	if parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return params, nil, nil, errors.New("versão do argon2id não suportada")
+	}
+
+	// Sscanf ignora o que vem depois do último número; a comparação com o
+	// texto remontado recusa sobras e zeros à esquerda.
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil ||
+		parts[3] != fmt.Sprintf("m=%d,t=%d,p=%d", params.memory, params.time, params.threads) {
+		return params, nil, nil, errors.New("parâmetros do argon2id malformados")
+	}
+	if params.memory == 0 || params.time == 0 || params.threads == 0 ||
+		params.memory > argon2MaxMemory || params.time > argon2MaxTime {
+		return params, nil, nil, errors.New("parâmetros do argon2id inválidos")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errors.New("salt do argon2id malformado")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("hash do argon2id malformado")
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,288 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parâmetros baixos só para os testes rodarem rápido.
+var testArgon2 = PasswordHashing{
+	Algorithm:     HashAlgorithmArgon2id,
+	BcryptCost:    bcrypt.MinCost,
+	Argon2Memory:  64,
+	Argon2Time:    1,
+	Argon2Threads: 1,
+}
+
+func usePasswordHashing(t *testing.T, cfg PasswordHashing) {
+	t.Helper()
+	previous := currentPasswordHashing()
+	if err := SetPasswordHashing(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetPasswordHashing(previous) })
+}
+
+func mustHash(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// argon2Hash monta um PHC com parâmetros arbitrários, como se tivesse sido
+// gravado por uma configuração anterior.
+func argon2Hash(password string, memory, time uint32, threads uint8, keyLen uint32) string {
+	salt := []byte("salt-de-16-bytes")
+	key := argon2.IDKey([]byte(password), salt, time, memory, threads, keyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestHashPasswordArgon2id(t *testing.T) {
+	usePasswordHashing(t, testArgon2)
+
+	hash := mustHash(t, "senha-correta")
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("formato PHC inesperado: %s", hash)
+	}
+	if other := mustHash(t, "senha-correta"); other == hash {
+		t.Fatal("dois hashes da mesma senha deveriam ter salts diferentes")
+	}
+
+	params, salt, key, err := parseArgon2Hash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != (argon2Params{memory: 64, time: 1, threads: 1}) || len(salt) != argon2SaltLength || len(key) != argon2KeyLength {
+		t.Fatalf("parse: %+v, salt %d bytes, key %d bytes", params, len(salt), len(key))
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"senha-correta", true},
+		{"senha-errada", false},
+		{"Senha-correta", false},
+		{"senha-correta ", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := CheckPassword(tt.password, hash); got != tt.want {
+			t.Errorf("CheckPassword(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPasswordOtherArgon2Params(t *testing.T) {
+	usePasswordHashing(t, testArgon2)
+
+	// Hashes gravados com outros parâmetros continuam valendo: os do hash
+	// mandam, não os da configuração.
+	tests := map[string]string{
+		"mais memória":   argon2Hash("senha", 128, 1, 1, argon2KeyLength),
+		"mais passadas":  argon2Hash("senha", 64, 2, 1, argon2KeyLength),
+		"mais threads":   argon2Hash("senha", 64, 1, 4, argon2KeyLength),
+		"chave de 16":    argon2Hash("senha", 64, 1, 1, 16),
+		"chave de 64":    argon2Hash("senha", 64, 1, 1, 64),
+		"mesmos valores": argon2Hash("senha", 64, 1, 1, argon2KeyLength),
+	}
+	for name, hash := range tests {
+		if !CheckPassword("senha", hash) {
+			t.Errorf("%s: senha correta deveria conferir", name)
+		}
+		if CheckPassword("outra", hash) {
+			t.Errorf("%s: senha errada não deveria conferir", name)
+		}
+	}
+}
+
+func TestLegacyBcrypt(t *testing.T) {
+	usePasswordHashing(t, testArgon2)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("senha-antiga"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// $2a$ é o que o Go gera; $2b$ e $2y$ vêm de outras bibliotecas e são o
+	// mesmo algoritmo.
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		hash := prefix + string(legacy[4:])
+		if !CheckPassword("senha-antiga", hash) {
+			t.Errorf("%s: hash bcrypt legado deveria conferir", prefix)
+		}
+		if CheckPassword("senha-nova", hash) {
+			t.Errorf("%s: senha errada não deveria conferir", prefix)
+		}
+		if !NeedsRehash(hash) {
+			t.Errorf("%s: bcrypt com argon2id configurado deveria ser refeito", prefix)
+		}
+	}
+}
+
+func TestCheckPasswordMalformed(t *testing.T) {
+	usePasswordHashing(t, testArgon2)
+
+	valid := argon2Hash("senha", 64, 1, 1, argon2KeyLength)
+	parts := strings.Split(valid, "$")
+	salt, key := parts[4], parts[5]
+	with := func(version, params string) string {
+		return "$argon2id$" + version + "$" + params + "$" + salt + "$" + key
+	}
+
+	tests := map[string]string{
+		"vazio":                  "",
+		"só o prefixo":           "$argon2id$",
+		"sem hash":               "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+		"sem salt e hash":        "$argon2id$v=19$m=64,t=1,p=1",
+		"sem parâmetros":         "$argon2id$v=19",
+		"partes a mais":          valid + "$extra",
+		"hash vazio":             "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"versão 16":              with("v=16", "m=64,t=1,p=1"),
+		"versão ausente":         with("", "m=64,t=1,p=1"),
+		"versão com sobra":       with("v=19x", "m=64,t=1,p=1"),
+		"parâmetros fora ordem":  with("v=19", "t=1,m=64,p=1"),
+		"parâmetro faltando":     with("v=19", "m=64,t=1"),
+		"parâmetros com sobra":   with("v=19", "m=64,t=1,p=1,k=2"),
+		"zero à esquerda":        with("v=19", "m=064,t=1,p=1"),
+		"memória zero":           with("v=19", "m=0,t=1,p=1"),
+		"passadas zero":          with("v=19", "m=64,t=0,p=1"),
+		"threads zero":           with("v=19", "m=64,t=1,p=0"),
+		"threads acima de uint8": with("v=19", "m=64,t=1,p=256"),
+		"memória negativa":       with("v=19", "m=-64,t=1,p=1"),
+		"memória de 4 TiB":       with("v=19", "m=4294967295,t=1,p=1"),
+		"memória acima de int32": with("v=19", "m=4294967296,t=1,p=1"),
+		"passadas demais":        with("v=19", "m=64,t=1000000,p=1"),
+		"salt não base64":        "$argon2id$v=19$m=64,t=1,p=1$***$" + key,
+		"hash não base64":        "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$***",
+		"salt com padding":       "$argon2id$v=19$m=64,t=1,p=1$" + salt + "==$" + key,
+		"argon2i":                strings.Replace(valid, "$argon2id$", "$argon2i$", 1),
+		"bcrypt truncado":        "$2a$04$abc",
+		"bcrypt custo inválido":  "$2a$99$" + strings.Repeat("a", 53),
+		"texto puro":             "senha",
+	}
+
+	for name, hash := range tests {
+		// Um hash estragado no banco não pode derrubar o login.
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic %v", name, r)
+				}
+			}()
+			if CheckPassword("senha", hash) {
+				t.Errorf("%s: hash malformado não deveria conferir", name)
+			}
+			if !NeedsRehash(hash) {
+				t.Errorf("%s: hash malformado deveria ser refeito", name)
+			}
+			if strings.HasPrefix(hash, "$argon2id$") {
+				if _, _, _, err := parseArgon2Hash(hash); err == nil {
+					t.Errorf("%s: parseArgon2Hash deveria falhar", name)
+				}
+			}
+		}()
+	}
+
+	if !CheckPassword("senha", valid) {
+		t.Fatal("controle: hash bem formado deveria conferir")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	usePasswordHashing(t, testArgon2)
+
+	current := mustHash(t, "senha")
+	bcryptMin, _ := bcrypt.GenerateFromPassword([]byte("senha"), bcrypt.MinCost)
+	bcryptHigher, _ := bcrypt.GenerateFromPassword([]byte("senha"), bcrypt.MinCost+1)
+
+	tests := []struct {
+		name string
+		cfg  PasswordHashing
+		hash string
+		want bool
+	}{
+		{"argon2id atual", testArgon2, current, false},
+		{"configuração com menos memória", withArgon2(32, 1, 1), current, true},
+		{"memória abaixo da atual", testArgon2, argon2Hash("senha", 32, 1, 1, argon2KeyLength), true},
+		{"passadas abaixo da atual", withArgon2(64, 2, 1), current, true},
+		{"threads diferentes", withArgon2(64, 1, 2), current, true},
+		{"chave menor", testArgon2, argon2Hash("senha", 64, 1, 1, 16), true},
+		{"bcrypt com argon2id configurado", testArgon2, string(bcryptMin), true},
+		{"argon2id com bcrypt configurado", withBcrypt(bcrypt.MinCost), current, true},
+		{"bcrypt no custo atual", withBcrypt(bcrypt.MinCost), string(bcryptMin), false},
+		{"bcrypt abaixo do custo atual", withBcrypt(bcrypt.MinCost + 1), string(bcryptMin), true},
+		{"bcrypt acima do custo atual", withBcrypt(bcrypt.MinCost), string(bcryptHigher), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usePasswordHashing(t, tt.cfg)
+			if got := NeedsRehash(tt.hash); got != tt.want {
+				t.Fatalf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// O ciclo do login: confere com o hash antigo, refaz e o novo já está em dia.
+func TestRehashUpgrade(t *testing.T) {
+	usePasswordHashing(t, withBcrypt(bcrypt.MinCost))
+	old := mustHash(t, "senha")
+
+	usePasswordHashing(t, testArgon2)
+	if !CheckPassword("senha", old) || !NeedsRehash(old) {
+		t.Fatal("hash bcrypt deveria conferir e pedir rehash")
+	}
+
+	upgraded := mustHash(t, "senha")
+	if !CheckPassword("senha", upgraded) || NeedsRehash(upgraded) {
+		t.Fatalf("hash refeito deveria conferir e estar em dia: %s", upgraded)
+	}
+}
+
+func TestSetPasswordHashingRejects(t *testing.T) {
+	usePasswordHashing(t, testArgon2)
+
+	tests := map[string]PasswordHashing{
+		"algoritmo desconhecido": {Algorithm: "scrypt"},
+		"algoritmo vazio":        {},
+		"argon2id sem memória":   withArgon2(0, 1, 1),
+		"argon2id sem passadas":  withArgon2(64, 0, 1),
+		"argon2id sem threads":   withArgon2(64, 1, 0),
+		"argon2id memória alta":  withArgon2(argon2MaxMemory+1, 1, 1),
+		"argon2id passadas alta": withArgon2(64, argon2MaxTime+1, 1),
+		"bcrypt custo baixo":     withBcrypt(bcrypt.MinCost - 1),
+		"bcrypt custo alto":      withBcrypt(bcrypt.MaxCost + 1),
+	}
+	for name, cfg := range tests {
+		if err := SetPasswordHashing(cfg); err == nil {
+			t.Errorf("%s: deveria falhar", name)
+		}
+	}
+
+	if currentPasswordHashing() != testArgon2 {
+		t.Fatal("configuração inválida não deveria substituir a atual")
+	}
+}
+
+func withArgon2(memory, time uint32, threads uint8) PasswordHashing {
+	cfg := testArgon2
+	cfg.Argon2Memory, cfg.Argon2Time, cfg.Argon2Threads = memory, time, threads
+	return cfg
+}
+
+func withBcrypt(cost int) PasswordHashing {
+	cfg := testArgon2
+	cfg.Algorithm, cfg.BcryptCost = HashAlgorithmBcrypt, cost
+	return cfg
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// HashToken gera o SHA-256 (hex) de um token opaco de alta entropia.
+// Usado para guardar tokens no banco sem poder reconstruí-los em caso
+// de vazamento. NÃO usar para senhas — para isso existe HashPassword.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Alfabeto base32 (RFC 4648) em minúsculas: 32 símbolos, então b&31
+// distribui sem viés e não há confusão entre 0/o ou 1/l.
+const recoveryAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// GenerateRecoveryCode gera um código legível no formato "xxxxx-xxxxx",
+// pensado para ser anotado em papel pelo usuário.
+func GenerateRecoveryCode() (string, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("erro ao gerar código de recuperação: %w", err)
+	}
+
+	var sb strings.Builder
+	for i, b := range bytes {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(recoveryAlphabet[b&31])
+	}
+	return sb.String(), nil
+}
+
+// NormalizeRecoveryCode remove espaços/hífens e padroniza a caixa,
+// para que "ABCDE-FGHIJ" e "abcdefghij" resultem no mesmo hash.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// PersonalTokenPrefix identifica um personal access token à primeira
+// vista (e para scanners de segredos em repositórios).
+const PersonalTokenPrefix = "lbp_"
+
+// GeneratePersonalToken gera um personal access token e o trecho inicial
+// que pode ser exibido para o usuário reconhecê-lo depois.
+func GeneratePersonalToken() (token, displayPrefix string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("erro ao gerar token: %w", err)
+	}
+
+	token = PersonalTokenPrefix + base64.RawURLEncoding.EncodeToString(bytes)
+	return token, token[:len(PersonalTokenPrefix)+8], nil
+}
+
+// Escopos de personal access token: "read" só permite métodos de
+// leitura; "write" libera todos.
+const (
+	PersonalTokenScopeRead  = "read"
+	PersonalTokenScopeWrite = "write"
+)
@@ -0,0 +1,195 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// encodeCBOR é o inverso de decodeCBOR para os tipos que os testes usam
+// (maps com chaves ordenadas, como a forma canônica do CTAP2).
+func encodeCBOR(value any) []byte {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case nil:
+		return []byte{0xf6}
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []any:
+		out := cborHead(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case map[any]any:
+		keys := make([][]byte, 0, len(v))
+		encoded := map[string][]byte{}
+		for key, item := range v {
+			k := encodeCBOR(key)
+			keys = append(keys, k)
+			encoded[string(k)] = encodeCBOR(item)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+
+		out := cborHead(5, uint64(len(v)))
+		for _, k := range keys {
+			out = append(out, k...)
+			out = append(out, encoded[string(k)]...)
+		}
+		return out
+	}
+	panic("encodeCBOR: tipo não suportado")
+}
+
+func cborHead(major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return []byte{major | byte(n)}
+	case n <= 0xff:
+		return []byte{major | 24, byte(n)}
+	case n <= 0xffff:
+		return []byte{major | 25, byte(n >> 8), byte(n)}
+	case n <= 0xffffffff:
+		return []byte{major | 26, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+	return []byte{major | 27, byte(n >> 56), byte(n >> 48), byte(n >> 40), byte(n >> 32),
+		byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// Vetores do Apêndice A da RFC 8949.
+var cborVectors = []struct {
+	hex  string
+	want any
+}{
+	{"00", int64(0)},
+	{"17", int64(23)},
+	{"1818", int64(24)},
+	{"1903e8", int64(1000)},
+	{"1a000f4240", int64(1000000)},
+	{"1b000000e8d4a51000", int64(1000000000000)},
+	{"20", int64(-1)},
+	{"3903e7", int64(-1000)},
+	{"3b7fffffffffffffff", int64(-1 << 63)},
+	{"f4", false},
+	{"f5", true},
+	{"f6", nil},
+	{"4401020304", []byte{1, 2, 3, 4}},
+	{"60", ""},
+	{"6449455446", "IETF"},
+	{"62c3bc", "ü"},
+	{"83010203", []any{int64(1), int64(2), int64(3)}},
+	{"8301820203820405", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+	{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+	{"a26161016162820203", map[any]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+}
+
+func TestDecodeCBORVectors(t *testing.T) {
+	for _, tt := range cborVectors {
+		data := mustHex(t, tt.hex)
+		got, n, err := decodeCBOR(data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.hex, err)
+			continue
+		}
+		if n != len(data) {
+			t.Errorf("%s: consumiu %d de %d bytes", tt.hex, n, len(data))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeCBORTrailingBytes(t *testing.T) {
+	// A chave COSE vem seguida de extensões: só o primeiro item é lido.
+	value, n, err := decodeCBOR([]byte{0x01, 0xff, 0xff})
+	if err != nil || value != int64(1) || n != 1 {
+		t.Fatalf("got %v, %d, %v", value, n, err)
+	}
+}
+
+func TestDecodeCBORTruncated(t *testing.T) {
+	for _, tt := range cborVectors {
+		data := mustHex(t, tt.hex)
+		for i := 0; i < len(data); i++ {
+			if _, _, err := decodeCBOR(data[:i]); err == nil {
+				t.Errorf("%s truncado em %d bytes deveria falhar", tt.hex, i)
+			}
+		}
+	}
+}
+
+func TestDecodeCBORHostile(t *testing.T) {
+	tests := map[string][]byte{
+		"byte string de 2^64-1 bytes": mustHex(t, "5bffffffffffffffff00"),
+		"text string de 4 GiB":        mustHex(t, "7affffffff00"),
+		"array de 2^64-1 itens":       mustHex(t, "9bffffffffffffffff00"),
+		"map de 2^32-1 pares":         mustHex(t, "baffffffff0000"),
+		"inteiro acima de int64":      mustHex(t, "1bffffffffffffffff"),
+		"negativo abaixo de int64":    mustHex(t, "3b8000000000000000"),
+		"tamanho indefinido":          mustHex(t, "9f01ff"),
+		"tag":                         mustHex(t, "c074323031332d30332d32315432303a30343a30305a"),
+		"float":                       mustHex(t, "f93c00"),
+		"info reservado (28)":         mustHex(t, "1c"),
+		"chave de map byte string":    mustHex(t, "a1410000"),
+		"chave de map array":          mustHex(t, "a1800000"),
+		"vazio":                       {},
+	}
+	for name, data := range tests {
+		if _, _, err := decodeCBOR(data); err == nil {
+			t.Errorf("%s: deveria falhar", name)
+		}
+	}
+}
+
+func TestDecodeCBORDepth(t *testing.T) {
+	nest := func(prefix []byte, depth int) []byte {
+		data := bytes.Repeat(prefix, depth)
+		return append(data, 0x00)
+	}
+
+	if _, _, err := decodeCBOR(nest([]byte{0x81}, maxCBORDepth)); err != nil {
+		t.Fatalf("%d níveis deveriam passar: %v", maxCBORDepth, err)
+	}
+
+	hostile := map[string][]byte{
+		"arrays":     nest([]byte{0x81}, maxCBORDepth+1),
+		"maps":       nest([]byte{0xa1, 0x01}, maxCBORDepth+1),
+		"100 níveis": nest([]byte{0x81}, 100),
+	}
+	for name, data := range hostile {
+		if _, _, err := decodeCBOR(data); err == nil {
+			t.Errorf("%s: aninhamento além de %d deveria falhar", name, maxCBORDepth)
+		}
+	}
+}
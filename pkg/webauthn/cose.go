@@ -0,0 +1,111 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Parâmetros de chave COSE (RFC 9053) usados pelos autenticadores.
+const (
+	coseKty = 1
+	coseAlg = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// parseCOSEKey converte a chave COSE do autenticador para DER (PKIX),
+// formato que guardamos no banco e que a verificação lê de volta.
+func parseCOSEKey(key map[any]any) ([]byte, int64, error) {
+	kty, _ := key[int64(coseKty)].(int64)
+	alg, _ := key[int64(coseAlg)].(int64)
+
+	var publicKey crypto.PublicKey
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("chave EC2 inválida")
+		}
+		ecKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !ecKey.Curve.IsOnCurve(ecKey.X, ecKey.Y) {
+			return nil, 0, errors.New("chave EC2 fora da curva")
+		}
+		publicKey = ecKey
+
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("chave OKP inválida")
+		}
+		publicKey = ed25519.PublicKey(x)
+
+	case kty == coseKtyRSA && alg == AlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("chave RSA inválida")
+		}
+		publicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+	default:
+		return nil, 0, fmt.Errorf("algoritmo COSE não suportado: kty=%d alg=%d", kty, alg)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao serializar chave: %w", err)
+	}
+	return der, alg, nil
+}
+
+// verifySignature confere a assinatura com a chave guardada. O algoritmo
+// vem do registro, nunca da asserção.
+func verifySignature(publicKeyDER []byte, algorithm int64, data, signature []byte) error {
+	parsed, err := x509.ParsePKIXPublicKey(publicKeyDER)
+	if err != nil {
+		return fmt.Errorf("chave pública inválida: %w", err)
+	}
+
+	invalid := errors.New("assinatura inválida")
+
+	switch algorithm {
+	case AlgES256:
+		key, ok := parsed.(*ecdsa.PublicKey)
+		digest := sha256.Sum256(data)
+		if !ok || !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return invalid
+		}
+	case AlgEdDSA:
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(key, data, signature) {
+			return invalid
+		}
+	case AlgRS256:
+		key, ok := parsed.(*rsa.PublicKey)
+		digest := sha256.Sum256(data)
+		if !ok || rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return invalid
+		}
+	default:
+		return fmt.Errorf("algoritmo não suportado: %d", algorithm)
+	}
+
+	return nil
+}
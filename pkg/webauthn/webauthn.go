@@ -0,0 +1,474 @@
+// Package webauthn implementa o lado do servidor (relying party) das
+// cerimônias de registro e autenticação WebAuthn / passkeys.
+//
+// Atestação não é verificada: as opções pedem attestation "none" e não
+// mantemos lista de autenticadores confiáveis, então só interessam a
+// chave pública e o contador.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Flags do authenticator data.
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// Algoritmos COSE suportados, na ordem de preferência anunciada.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+type Config struct {
+	// RPID é o domínio (sem esquema/porta) ao qual as passkeys ficam presas.
+	RPID   string
+	RPName string
+	// Origins são as origens (esquema://host[:porta]) aceitas no clientDataJSON.
+	Origins []string
+	// Timeout é repassado ao navegador nas opções.
+	Timeout time.Duration
+}
+
+type RelyingParty struct {
+	cfg Config
+}
+
+func New(cfg Config) (*RelyingParty, error) {
+	if cfg.RPID == "" {
+		return nil, errors.New("webauthn: RPID é obrigatório")
+	}
+	if len(cfg.Origins) == 0 {
+		return nil, errors.New("webauthn: ao menos uma origem é obrigatória")
+	}
+	if cfg.RPName == "" {
+		cfg.RPName = cfg.RPID
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	return &RelyingParty{cfg: cfg}, nil
+}
+
+// ============================================
+// OPÇÕES (navigator.credentials.create/get)
+// ============================================
+
+// Campos binários trafegam em base64url sem padding; o frontend converte
+// para ArrayBuffer antes de chamar o navegador.
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// NewChallenge gera 32 bytes aleatórios em base64url.
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("erro ao gerar challenge: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Descriptor monta o descritor de uma credencial já cadastrada.
+func Descriptor(credentialID []byte, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{
+		Type:       "public-key",
+		ID:         base64.RawURLEncoding.EncodeToString(credentialID),
+		Transports: transports,
+	}
+}
+
+// CreationOptions pede uma passkey (chave residente, com verificação do
+// usuário) para userHandle. exclude evita cadastrar o mesmo autenticador duas vezes.
+func (rp *RelyingParty) CreationOptions(challenge string, userHandle []byte, name, displayName string, exclude []CredentialDescriptor) CreationOptions {
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return CreationOptions{
+		Challenge: challenge,
+		RP:        RelyingPartyEntity{ID: rp.cfg.RPID, Name: rp.cfg.RPName},
+		User: UserEntity{
+			ID:          base64.RawURLEncoding.EncodeToString(userHandle),
+			Name:        name,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            rp.cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions pede uma asserção. Sem allow, o navegador oferece as
+// passkeys (discoverable) que o usuário tiver para este RPID.
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor, userVerification string) RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.cfg.Timeout.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: allow,
+		UserVerification: userVerification,
+	}
+}
+
+// ============================================
+// RESPOSTAS DO NAVEGADOR
+// ============================================
+
+// RegistrationResponse é o PublicKeyCredential devolvido por
+// navigator.credentials.create, com os buffers em base64url.
+type RegistrationResponse struct {
+	ID       string `json:"id" validate:"required"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type" validate:"required"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON" validate:"required"`
+		AttestationObject string   `json:"attestationObject" validate:"required"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse é o PublicKeyCredential devolvido por
+// navigator.credentials.get, com os buffers em base64url.
+type AssertionResponse struct {
+	ID       string `json:"id" validate:"required"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type" validate:"required"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+		AuthenticatorData string `json:"authenticatorData" validate:"required"`
+		Signature         string `json:"signature" validate:"required"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// CredentialID decodifica o ID da credencial usada na asserção.
+func (a *AssertionResponse) CredentialID() ([]byte, error) {
+	id := a.RawID
+	if id == "" {
+		id = a.ID
+	}
+	return decodeBase64URL(id)
+}
+
+// UserHandle devolve o user.id informado no registro (nil se ausente).
+func (a *AssertionResponse) UserHandle() ([]byte, error) {
+	if a.Response.UserHandle == "" {
+		return nil, nil
+	}
+	return decodeBase64URL(a.Response.UserHandle)
+}
+
+// Challenge extrai o challenge do clientDataJSON, sem validar nada. Serve
+// para localizar o desafio guardado antes de chamar VerifyAssertion.
+func (a *AssertionResponse) Challenge() (string, error) {
+	raw, err := decodeBase64URL(a.Response.ClientDataJSON)
+	if err != nil {
+		return "", err
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", errors.New("clientDataJSON inválido")
+	}
+	return strings.TrimRight(data.Challenge, "="), nil
+}
+
+// ============================================
+// VERIFICAÇÃO
+// ============================================
+
+// Credential é o que precisa ser guardado após o registro.
+type Credential struct {
+	ID []byte
+	// PublicKey é a chave em DER (PKIX).
+	PublicKey    []byte
+	Algorithm    int64
+	SignCount    uint32
+	Transports   []string
+	UserVerified bool
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+	algorithm    int64
+}
+
+// VerifyRegistration confere a resposta de navigator.credentials.create
+// contra o challenge emitido e extrai a credencial.
+func (rp *RelyingParty) VerifyRegistration(resp RegistrationResponse, challenge string, requireUV bool) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, errors.New("tipo de credencial inválido")
+	}
+
+	clientDataRaw, err := decodeBase64URL(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.New("clientDataJSON inválido")
+	}
+	if err := rp.verifyClientData(clientDataRaw, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	attestationRaw, err := decodeBase64URL(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.New("attestationObject inválido")
+	}
+	decoded, _, err := decodeCBOR(attestationRaw)
+	if err != nil {
+		return nil, fmt.Errorf("attestationObject inválido: %w", err)
+	}
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, errors.New("attestationObject inválido")
+	}
+	authDataRaw, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestationObject sem authData")
+	}
+
+	authData, err := parseAuthenticatorData(authDataRaw)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, requireUV); err != nil {
+		return nil, err
+	}
+	if authData.credentialID == nil {
+		return nil, errors.New("authData sem credencial")
+	}
+
+	// O id informado pelo navegador precisa ser o mesmo que o autenticador assinou.
+	if id, err := decodeBase64URL(resp.ID); err != nil || !bytes.Equal(id, authData.credentialID) {
+		return nil, errors.New("id da credencial não confere")
+	}
+
+	return &Credential{
+		ID:           authData.credentialID,
+		PublicKey:    authData.publicKey,
+		Algorithm:    authData.algorithm,
+		SignCount:    authData.signCount,
+		Transports:   resp.Response.Transports,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// AssertionResult é o estado a persistir após uma asserção válida.
+type AssertionResult struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// VerifyAssertion confere a resposta de navigator.credentials.get contra
+// o challenge emitido e a credencial cadastrada. Um contador que não
+// avança indica um autenticador clonado e é recusado.
+func (rp *RelyingParty) VerifyAssertion(resp AssertionResponse, challenge string, publicKey []byte, algorithm int64, storedSignCount uint32, requireUV bool) (*AssertionResult, error) {
+	if resp.Type != "public-key" {
+		return nil, errors.New("tipo de credencial inválido")
+	}
+
+	clientDataRaw, err := decodeBase64URL(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.New("clientDataJSON inválido")
+	}
+	if err := rp.verifyClientData(clientDataRaw, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+
+	authDataRaw, err := decodeBase64URL(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, errors.New("authenticatorData inválido")
+	}
+	authData, err := parseAuthenticatorData(authDataRaw)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, requireUV); err != nil {
+		return nil, err
+	}
+
+	signature, err := decodeBase64URL(resp.Response.Signature)
+	if err != nil {
+		return nil, errors.New("assinatura inválida")
+	}
+
+	clientDataHash := sha256.Sum256(clientDataRaw)
+	signed := append(append([]byte(nil), authDataRaw...), clientDataHash[:]...)
+	if err := verifySignature(publicKey, algorithm, signed, signature); err != nil {
+		return nil, err
+	}
+
+	// Autenticadores sem contador mandam sempre 0; só comparamos quando há contador.
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return nil, errors.New("contador de assinaturas não avançou (possível autenticador clonado)")
+	}
+
+	return &AssertionResult{
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, wantType, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return errors.New("clientDataJSON inválido")
+	}
+
+	if data.Type != wantType {
+		return errors.New("tipo de cerimônia inválido")
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(strings.TrimRight(data.Challenge, "=")), []byte(challenge)) != 1 {
+		return errors.New("challenge inválido")
+	}
+	if !slices.Contains(rp.cfg.Origins, data.Origin) {
+		return fmt.Errorf("origem não permitida: %s", data.Origin)
+	}
+	if data.CrossOrigin {
+		return errors.New("cerimônia cross-origin não permitida")
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(data *authenticatorData, requireUV bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.cfg.RPID))
+	if subtle.ConstantTimeCompare(data.rpIDHash, rpIDHash[:]) != 1 {
+		return errors.New("rpId não confere")
+	}
+	if data.flags&flagUserPresent == 0 {
+		return errors.New("presença do usuário não confirmada")
+	}
+	if requireUV && data.flags&flagUserVerified == 0 {
+		return errors.New("verificação do usuário (PIN/biometria) é obrigatória")
+	}
+	return nil
+}
+
+// parseAuthenticatorData lê o layout binário fixo:
+// rpIdHash(32) | flags(1) | signCount(4) | [aaguid(16) | len(2) | credId | coseKey] | [extensões]
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("authenticatorData truncado")
+	}
+
+	data := &authenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	if data.flags&flagAttestedData == 0 {
+		return data, nil
+	}
+
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("authenticatorData truncado")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, errors.New("id de credencial inválido")
+	}
+	data.credentialID = append([]byte(nil), rest[:idLen]...)
+	rest = rest[idLen:]
+
+	coseKey, _, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("chave COSE inválida: %w", err)
+	}
+	key, ok := coseKey.(map[any]any)
+	if !ok {
+		return nil, errors.New("chave COSE inválida")
+	}
+
+	data.publicKey, data.algorithm, err = parseCOSEKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// decodeBase64URL aceita base64url com ou sem padding.
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,353 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+func newTestRP(t *testing.T) *RelyingParty {
+	t.Helper()
+	rp, err := New(Config{RPID: testRPID, Origins: []string{testOrigin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp
+}
+
+// authenticator simula um autenticador com um par de chaves.
+type authenticator struct {
+	name         string
+	credentialID []byte
+	coseKey      map[any]any
+	sign         func(data []byte) []byte
+}
+
+func newES256(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	point, err := key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := point.Bytes() // 0x04 | X | Y
+
+	return &authenticator{
+		name:         "ES256",
+		credentialID: []byte("credencial-es256"),
+		coseKey: map[any]any{
+			int64(coseKty): int64(coseKtyEC2), int64(coseAlg): AlgES256,
+			int64(-1): int64(coseCrvP256), int64(-2): raw[1:33], int64(-3): raw[33:],
+		},
+		sign: func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sig
+		},
+	}
+}
+
+func newEdDSA(t *testing.T) *authenticator {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &authenticator{
+		name:         "EdDSA",
+		credentialID: []byte("credencial-eddsa"),
+		coseKey: map[any]any{
+			int64(coseKty): int64(coseKtyOKP), int64(coseAlg): AlgEdDSA,
+			int64(-1): int64(coseCrvEd25519), int64(-2): []byte(public),
+		},
+		sign: func(data []byte) []byte {
+			return ed25519.Sign(private, data)
+		},
+	}
+}
+
+func newRS256(t *testing.T) *authenticator {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &authenticator{
+		name:         "RS256",
+		credentialID: []byte("credencial-rs256"),
+		coseKey: map[any]any{
+			int64(coseKty): int64(coseKtyRSA), int64(coseAlg): AlgRS256,
+			int64(-1): key.N.Bytes(), int64(-2): big.NewInt(int64(key.E)).Bytes(),
+		},
+		sign: func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sig
+		},
+	}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func clientDataJSON(t *testing.T, ceremony, challenge, origin string) []byte {
+	t.Helper()
+	data, err := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// authData monta rpIdHash | flags | signCount | [attestedCredentialData].
+func authData(rpID string, flags byte, signCount uint32, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	return append(data, attested...)
+}
+
+func (a *authenticator) attestedData() []byte {
+	data := make([]byte, 16) // aaguid
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+	data = append(data, a.credentialID...)
+	return append(data, encodeCBOR(a.coseKey)...)
+}
+
+func (a *authenticator) register(t *testing.T, challenge string) RegistrationResponse {
+	t.Helper()
+
+	attestation := map[any]any{
+		"fmt":      "none",
+		"attStmt":  map[any]any{},
+		"authData": authData(testRPID, flagUserPresent|flagUserVerified|flagAttestedData, 0, a.attestedData()),
+	}
+
+	var resp RegistrationResponse
+	resp.ID = b64(a.credentialID)
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = b64(clientDataJSON(t, "webauthn.create", challenge, testOrigin))
+	resp.Response.AttestationObject = b64(encodeCBOR(attestation))
+	return resp
+}
+
+// assert assina authData | sha256(clientDataJSON), como o autenticador.
+func (a *authenticator) assert(t *testing.T, challenge string, data []byte) AssertionResponse {
+	t.Helper()
+
+	client := clientDataJSON(t, "webauthn.get", challenge, testOrigin)
+	clientHash := sha256.Sum256(client)
+
+	var resp AssertionResponse
+	resp.ID = b64(a.credentialID)
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = b64(client)
+	resp.Response.AuthenticatorData = b64(data)
+	resp.Response.Signature = b64(a.sign(append(append([]byte(nil), data...), clientHash[:]...)))
+	return resp
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := newTestRP(t)
+
+	for _, auth := range []*authenticator{newES256(t), newEdDSA(t), newRS256(t)} {
+		t.Run(auth.name, func(t *testing.T) {
+			credential, err := rp.VerifyRegistration(auth.register(t, "desafio-registro"), "desafio-registro", true)
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+			if string(credential.ID) != string(auth.credentialID) || credential.Algorithm != auth.coseKey[int64(coseAlg)] {
+				t.Fatalf("credencial inesperada: %+v", credential)
+			}
+
+			data := authData(testRPID, flagUserPresent|flagUserVerified, 7, nil)
+			result, err := rp.VerifyAssertion(auth.assert(t, "desafio-login", data), "desafio-login",
+				credential.PublicKey, credential.Algorithm, 6, true)
+			if err != nil {
+				t.Fatalf("VerifyAssertion: %v", err)
+			}
+			if result.SignCount != 7 || !result.UserVerified {
+				t.Fatalf("resultado inesperado: %+v", result)
+			}
+
+			// Assinatura adulterada
+			resp := auth.assert(t, "desafio-login", data)
+			signature, _ := decodeBase64URL(resp.Response.Signature)
+			signature[len(signature)-1] ^= 0xff
+			resp.Response.Signature = b64(signature)
+			if _, err := rp.VerifyAssertion(resp, "desafio-login", credential.PublicKey, credential.Algorithm, 6, true); err == nil {
+				t.Fatal("assinatura adulterada deveria ser recusada")
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionRejects(t *testing.T) {
+	rp := newTestRP(t)
+	auth := newES256(t)
+
+	credential, err := rp.VerifyRegistration(auth.register(t, "reg"), "reg", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const challenge = "desafio"
+	valid := authData(testRPID, flagUserPresent|flagUserVerified, 11, nil)
+
+	tests := []struct {
+		name      string
+		resp      AssertionResponse
+		challenge string
+		stored    uint32
+		requireUV bool
+	}{
+		{"rpIdHash de outro domínio", auth.assert(t, challenge, authData("evil.example", flagUserPresent|flagUserVerified, 11, nil)), challenge, 10, true},
+		{"sem flag UP", auth.assert(t, challenge, authData(testRPID, flagUserVerified, 11, nil)), challenge, 10, false},
+		{"sem flag UV quando exigida", auth.assert(t, challenge, authData(testRPID, flagUserPresent, 11, nil)), challenge, 10, true},
+		{"contador regrediu", auth.assert(t, challenge, authData(testRPID, flagUserPresent|flagUserVerified, 5, nil)), challenge, 10, true},
+		{"contador repetido", auth.assert(t, challenge, valid), challenge, 11, true},
+		{"contador zerado após uso", auth.assert(t, challenge, authData(testRPID, flagUserPresent|flagUserVerified, 0, nil)), challenge, 10, true},
+		{"challenge diferente", auth.assert(t, challenge, valid), "outro", 10, true},
+		{"challenge vazio", auth.assert(t, "", valid), "", 10, true},
+		{"authenticatorData truncado", auth.assert(t, challenge, valid[:36]), challenge, 10, true},
+	}
+
+	for _, tt := range tests {
+		if _, err := rp.VerifyAssertion(tt.resp, tt.challenge, credential.PublicKey, credential.Algorithm, tt.stored, tt.requireUV); err == nil {
+			t.Errorf("%s: deveria ser recusado", tt.name)
+		}
+	}
+
+	// O algoritmo vem do registro, não da asserção.
+	if _, err := rp.VerifyAssertion(auth.assert(t, challenge, valid), challenge, credential.PublicKey, AlgEdDSA, 10, true); err == nil {
+		t.Error("chave ES256 verificada como EdDSA deveria ser recusada")
+	}
+
+	// Sem contador (sempre 0) é aceito.
+	zero := authData(testRPID, flagUserPresent|flagUserVerified, 0, nil)
+	if _, err := rp.VerifyAssertion(auth.assert(t, challenge, zero), challenge, credential.PublicKey, credential.Algorithm, 0, true); err != nil {
+		t.Errorf("autenticador sem contador deveria passar: %v", err)
+	}
+}
+
+func TestVerifyAssertionClientData(t *testing.T) {
+	rp := newTestRP(t)
+	auth := newEdDSA(t)
+
+	credential, err := rp.VerifyRegistration(auth.register(t, "reg"), "reg", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := authData(testRPID, flagUserPresent|flagUserVerified, 1, nil)
+	tests := map[string][]byte{
+		"origem não permitida":  clientDataJSON(t, "webauthn.get", "c", "https://evil.example"),
+		"cerimônia de registro": clientDataJSON(t, "webauthn.create", "c", testOrigin),
+		"cross-origin":          []byte(`{"type":"webauthn.get","challenge":"c","origin":"` + testOrigin + `","crossOrigin":true}`),
+		"json inválido":         []byte(`{`),
+	}
+
+	for name, client := range tests {
+		clientHash := sha256.Sum256(client)
+		resp := auth.assert(t, "c", data)
+		resp.Response.ClientDataJSON = b64(client)
+		resp.Response.Signature = b64(auth.sign(append(append([]byte(nil), data...), clientHash[:]...)))
+
+		if _, err := rp.VerifyAssertion(resp, "c", credential.PublicKey, credential.Algorithm, 0, true); err == nil {
+			t.Errorf("%s: deveria ser recusado", name)
+		}
+	}
+}
+
+func TestVerifyRegistrationRejects(t *testing.T) {
+	rp := newTestRP(t)
+	auth := newES256(t)
+
+	withAuthData := func(data []byte) RegistrationResponse {
+		resp := auth.register(t, "c")
+		resp.Response.AttestationObject = b64(encodeCBOR(map[any]any{"fmt": "none", "attStmt": map[any]any{}, "authData": data}))
+		return resp
+	}
+	attested := byte(flagUserPresent | flagUserVerified | flagAttestedData)
+
+	wrongID := auth.register(t, "c")
+	wrongID.ID = b64([]byte("outra"))
+
+	tests := map[string]RegistrationResponse{
+		"rpIdHash de outro domínio": withAuthData(authData("evil.example", attested, 0, auth.attestedData())),
+		"sem flag UP":               withAuthData(authData(testRPID, flagUserVerified|flagAttestedData, 0, auth.attestedData())),
+		"sem flag UV":               withAuthData(authData(testRPID, flagUserPresent|flagAttestedData, 0, auth.attestedData())),
+		"sem credencial":            withAuthData(authData(testRPID, flagUserPresent|flagUserVerified, 0, nil)),
+		"credencial truncada":       withAuthData(authData(testRPID, attested, 0, auth.attestedData()[:20])),
+		"chave COSE truncada":       withAuthData(authData(testRPID, attested, 0, auth.attestedData()[:40])),
+		"id diferente do assinado":  wrongID,
+	}
+
+	for name, resp := range tests {
+		if _, err := rp.VerifyRegistration(resp, "c", true); err == nil {
+			t.Errorf("%s: deveria ser recusado", name)
+		}
+	}
+
+	badCBOR := auth.register(t, "c")
+	badCBOR.Response.AttestationObject = b64([]byte{0xa1, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	if _, err := rp.VerifyRegistration(badCBOR, "c", true); err == nil || !strings.Contains(err.Error(), "truncado") {
+		t.Errorf("attestationObject com tamanho gigante: got %v", err)
+	}
+}
+
+func TestParseCOSEKeyRejects(t *testing.T) {
+	es := newES256(t).coseKey
+	offCurve := map[any]any{}
+	for k, v := range es {
+		offCurve[k] = v
+	}
+	offCurve[int64(-3)] = make([]byte, 32)
+
+	rs := newRS256(t).coseKey
+	shortRSA := map[any]any{}
+	for k, v := range rs {
+		shortRSA[k] = v
+	}
+	shortRSA[int64(-1)] = make([]byte, 128)
+
+	tests := map[string]map[any]any{
+		"ponto fora da curva":     offCurve,
+		"RSA com módulo curto":    shortRSA,
+		"kty e alg trocados":      {int64(coseKty): int64(coseKtyOKP), int64(coseAlg): AlgES256},
+		"algoritmo desconhecido":  {int64(coseKty): int64(coseKtyEC2), int64(coseAlg): int64(-35)},
+		"Ed25519 com x curto":     {int64(coseKty): int64(coseKtyOKP), int64(coseAlg): AlgEdDSA, int64(-1): int64(coseCrvEd25519), int64(-2): []byte{1, 2, 3}},
+		"EC2 com curva diferente": {int64(coseKty): int64(coseKtyEC2), int64(coseAlg): AlgES256, int64(-1): int64(2), int64(-2): make([]byte, 32), int64(-3): make([]byte, 32)},
+		"vazio":                   {},
+	}
+
+	for name, key := range tests {
+		if _, _, err := parseCOSEKey(key); err == nil {
+			t.Errorf("%s: deveria ser recusado", name)
+		}
+	}
+}